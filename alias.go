@@ -0,0 +1,477 @@
+package multiconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// AliasPolicy defines what a loader does when a field is set by more than one
+// of its names in the same source, i.e: by both its name and one of the
+// aliases of its `aliases:"DBName,DatabaseName"` tag. Aliases are former
+// names of the field: file keys, environment variables and flags are
+// generated from them the same way they are from the field name.
+type AliasPolicy int
+
+const (
+	// AliasError returns an error. It's the default.
+	AliasError AliasPolicy = iota
+
+	// AliasPreferName uses the value set by the name of the field.
+	AliasPreferName
+
+	// AliasPreferAlias uses the value set by an alias. If several aliases
+	// are set, the first one in the tag wins.
+	AliasPreferAlias
+)
+
+// fieldAliases returns the aliases listed in the "aliases" tag of a field.
+func fieldAliases(tag string) []string {
+	var aliases []string
+	for _, alias := range strings.Split(tag, ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
+
+// choose returns the index of the name whose value is used among the names
+// of a field which are set, or -1 if none is set. names[0] is the name of the
+// field, the others are its aliases.
+func (p AliasPolicy) choose(names []string, set []bool) (int, error) {
+	var chosen []int
+	for i := range names {
+		if set[i] {
+			chosen = append(chosen, i)
+		}
+	}
+
+	switch {
+	case len(chosen) == 0:
+		return -1, nil
+	case len(chosen) == 1:
+		return chosen[0], nil
+	}
+
+	switch p {
+	case AliasPreferName:
+		return chosen[0], nil
+	case AliasPreferAlias:
+		if chosen[0] == 0 {
+			return chosen[1], nil
+		}
+
+		return chosen[0], nil
+	default:
+		return -1, fmt.Errorf("both %s and %s are set", names[chosen[0]], names[chosen[1]])
+	}
+}
+
+// hasAliases reports whether a field of the struct type t or of its nested
+// structs has an "aliases" tag.
+func hasAliases(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		if sf.Tag.Get("aliases") != "" {
+			return true
+		}
+
+		if isNestedStruct(sf.Type) && hasAliases(sf.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// aliasDecoder decodes the values of a config file lazily, so the values of
+// the aliases are decoded from the original file, and their errors have
+// their position in it.
+type aliasDecoder interface {
+	// table returns the values of the table raw by key, or false if raw is
+	// not a table.
+	table(raw any) (map[string]any, bool)
+
+	// array returns the items of the array raw, or false if raw is not an
+	// array.
+	array(raw any) ([]any, bool)
+
+	// decode decodes raw into v. path is the dotted path of raw.
+	decode(raw any, v reflect.Value, path string) error
+}
+
+// decodeAliases decodes the values of the keys of the table raw which are
+// aliases of the fields of the struct v into these fields, recursively. The
+// other keys are decoded beforehand by the regular decoders. Keys are
+// matched like the decoders do: case-insensitively for TOML and JSON.
+func decodeAliases(d aliasDecoder, raw any, v reflect.Value, format Format, path string, policy AliasPolicy) error {
+	m, ok := d.table(raw)
+	if !ok {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		key, ok := fieldKey(sf, format)
+		if !ok {
+			continue
+		}
+
+		names := fieldKeys(sf, key, format)
+		keys := make([]string, len(names))
+		set := make([]bool, len(names))
+		for k := range m {
+			for i, name := range names {
+				if keyEqual(k, name, format) {
+					keys[i], set[i] = k, true
+					break
+				}
+			}
+		}
+
+		display := make([]string, len(names))
+		for i, name := range names {
+			if path != "" {
+				name = path + "." + name
+			}
+			display[i] = fmt.Sprintf("%q", name)
+		}
+
+		chosen, err := policy.choose(display, set)
+		if err != nil {
+			return err
+		}
+
+		if chosen < 0 {
+			continue
+		}
+
+		child := key
+		if path != "" {
+			child = path + "." + key
+		}
+
+		// the value of the name of the field is already decoded
+		value := m[keys[chosen]]
+		if chosen > 0 {
+			if err := d.decode(value, v.Field(i), child); err != nil {
+				return err
+			}
+		}
+
+		if hasAliases(sf.Type) {
+			if err := decodeNestedAliases(d, value, v.Field(i), format, child, policy); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeNestedAliases decodes the aliases of the nested structs of the field
+// v from its value raw.
+func decodeNestedAliases(d aliasDecoder, raw any, v reflect.Value, format Format, path string, policy AliasPolicy) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	switch {
+	case isNestedStruct(v.Type()):
+		return decodeAliases(d, raw, v, format, path, policy)
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		items, ok := d.array(raw)
+		if !ok {
+			return nil
+		}
+
+		for i := 0; i < len(items) && i < v.Len(); i++ {
+			if err := decodeNestedAliases(d, items[i], v.Index(i), format, path, policy); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldKeys returns the key of the field sf in the given format followed by
+// its aliases, which are former field names cased like the field names are.
+func fieldKeys(sf reflect.StructField, key string, format Format) []string {
+	keys := []string{key}
+	for _, alias := range fieldAliases(sf.Tag.Get("aliases")) {
+		if format == FormatYAML {
+			alias = strings.ToLower(alias)
+		}
+		keys = append(keys, alias)
+	}
+
+	return keys
+}
+
+// keyEqual reports whether the key k of a file matches name like the
+// decoder of the format does.
+func keyEqual(k, name string, format Format) bool {
+	return k == name || (format != FormatYAML && strings.EqualFold(k, name))
+}
+
+// aliasField returns the field of the struct type t whose key or one of its
+// aliases matches the key k, and whether it's matched by an alias.
+func aliasField(t reflect.Type, k string, format Format) (reflect.StructField, bool, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		key, ok := fieldKey(sf, format)
+		if !ok {
+			continue
+		}
+
+		for j, name := range fieldKeys(sf, key, format) {
+			if keyEqual(k, name, format) {
+				return sf, j > 0, true
+			}
+		}
+	}
+
+	return reflect.StructField{}, false, false
+}
+
+// tomlAliases is the aliasDecoder of TOML files, whose values are
+// toml.Primitive, apart from the root table.
+type tomlAliases struct {
+	md toml.MetaData
+}
+
+func (d tomlAliases) table(raw any) (map[string]any, bool) {
+	var m map[string]toml.Primitive
+	switch raw := raw.(type) {
+	case map[string]toml.Primitive:
+		m = raw
+	case toml.Primitive:
+		if err := d.md.PrimitiveDecode(raw, &m); err != nil {
+			return nil, false
+		}
+	}
+
+	if m == nil {
+		return nil, false
+	}
+
+	table := make(map[string]any, len(m))
+	for k, v := range m {
+		table[k] = v
+	}
+
+	return table, true
+}
+
+func (d tomlAliases) array(raw any) ([]any, bool) {
+	p, ok := raw.(toml.Primitive)
+	if !ok {
+		return nil, false
+	}
+
+	var items []toml.Primitive
+	if err := d.md.PrimitiveDecode(p, &items); err != nil {
+		return nil, false
+	}
+
+	array := make([]any, len(items))
+	for i, item := range items {
+		array[i] = item
+	}
+
+	return array, true
+}
+
+func (d tomlAliases) decode(raw any, v reflect.Value, path string) error {
+	return d.md.PrimitiveDecode(raw.(toml.Primitive), v.Addr().Interface())
+}
+
+// jsonValue is a JSON value of a file and its offset in the file.
+type jsonValue struct {
+	data   json.RawMessage
+	offset int64
+}
+
+// jsonAliases is the aliasDecoder of JSON files, whose values are
+// jsonValue.
+type jsonAliases struct{}
+
+func (jsonAliases) table(raw any) (map[string]any, bool) {
+	value := raw.(jsonValue)
+	dec := json.NewDecoder(bytes.NewReader(value.data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+
+	table := map[string]any{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+
+		item, ok := nextJSONValue(dec, value.offset)
+		if !ok {
+			return nil, false
+		}
+
+		table[tok.(string)] = item
+	}
+
+	return table, true
+}
+
+func (jsonAliases) array(raw any) ([]any, bool) {
+	value := raw.(jsonValue)
+	dec := json.NewDecoder(bytes.NewReader(value.data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, false
+	}
+
+	var array []any
+	for dec.More() {
+		item, ok := nextJSONValue(dec, value.offset)
+		if !ok {
+			return nil, false
+		}
+
+		array = append(array, item)
+	}
+
+	return array, true
+}
+
+func (jsonAliases) decode(raw any, v reflect.Value, path string) error {
+	value := raw.(jsonValue)
+	err := json.Unmarshal(value.data, v.Addr().Interface())
+
+	// the errors are positioned in the file
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		typeErr.Offset += value.offset
+		typeErr.Field = strings.TrimSuffix(path+"."+typeErr.Field, ".")
+	}
+
+	return err
+}
+
+// nextJSONValue reads the next value of dec, which reads a value starting at
+// offset in the file.
+func nextJSONValue(dec *json.Decoder, offset int64) (jsonValue, bool) {
+	var data json.RawMessage
+	if err := dec.Decode(&data); err != nil {
+		return jsonValue{}, false
+	}
+
+	end := offset + dec.InputOffset()
+	return jsonValue{data: data, offset: end - int64(len(data))}, true
+}
+
+// yamlAliases is the aliasDecoder of YAML files, whose values are
+// *yaml.Node.
+type yamlAliases struct{}
+
+func (yamlAliases) table(raw any) (map[string]any, bool) {
+	n := yamlResolve(raw.(*yaml.Node))
+	if n.Kind != yaml.MappingNode {
+		return nil, false
+	}
+
+	table := map[string]any{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		table[n.Content[i].Value] = n.Content[i+1]
+	}
+
+	return table, true
+}
+
+func (yamlAliases) array(raw any) ([]any, bool) {
+	n := yamlResolve(raw.(*yaml.Node))
+	if n.Kind != yaml.SequenceNode {
+		return nil, false
+	}
+
+	array := make([]any, len(n.Content))
+	for i, item := range n.Content {
+		array[i] = item
+	}
+
+	return array, true
+}
+
+func (yamlAliases) decode(raw any, v reflect.Value, path string) error {
+	return raw.(*yaml.Node).Decode(v.Addr().Interface())
+}
+
+// yamlResolve returns the node referenced by n if it's an alias (i.e: *ref).
+func yamlResolve(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+
+	return n
+}
+
+// decodeDocument decodes the config file data of the given format into a
+// generic table.
+func decodeDocument(data []byte, format Format) (map[string]any, error) {
+	var doc map[string]any
+	switch format {
+	case FormatTOML:
+		if _, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("multiconfig: unsupported file format %q", format)
+	}
+
+	return doc, nil
+}
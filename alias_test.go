@@ -0,0 +1,165 @@
+package multiconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type AliasServer struct {
+	Name     string
+	Postgres struct {
+		Database string `aliases:"DBName,DB"`
+		Port     int
+	} `aliases:"PG"`
+}
+
+func TestAliasesFile(t *testing.T) {
+	tests := []struct {
+		format Format
+		data   string
+	}{
+		{FormatTOML, "Name = \"koding\"\n[Postgres]\nDBName = \"configdb\"\nPort = 5432\n"},
+		{FormatJSON, `{"Name": "koding", "pg": {"db": "configdb", "port": 5432}}`},
+		{FormatYAML, "name: koding\npg:\n  dbname: configdb\n  port: 5432\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			s := &AliasServer{}
+			l := newFileLoader(test.format, "", strings.NewReader(test.data), true)
+			require.NoError(t, l.Load(s))
+			require.Equal(t, "koding", s.Name)
+			require.Equal(t, "configdb", s.Postgres.Database)
+			require.Equal(t, 5432, s.Postgres.Port)
+		})
+	}
+}
+
+func TestAliasesFilePolicy(t *testing.T) {
+	data := "[Postgres]\nDatabase = \"new\"\nDBName = \"old\"\n"
+
+	s := &AliasServer{}
+	err := (&TOMLLoader{Reader: strings.NewReader(data)}).Load(s)
+	require.EqualError(t, err, `multiconfig: toml reader: both "Postgres.Database" and "Postgres.DBName" are set`)
+
+	s = &AliasServer{}
+	l := &TOMLLoader{Reader: strings.NewReader(data), AliasPolicy: AliasPreferName}
+	require.NoError(t, l.Load(s))
+	require.Equal(t, "new", s.Postgres.Database)
+
+	s = &AliasServer{}
+	l = &TOMLLoader{Reader: strings.NewReader(data), AliasPolicy: AliasPreferAlias}
+	require.NoError(t, l.Load(s))
+	require.Equal(t, "old", s.Postgres.Database)
+}
+
+func TestAliasesEnv(t *testing.T) {
+	t.Setenv("ALIASSERVER_PG_DBNAME", "old")
+
+	s := &AliasServer{}
+	require.NoError(t, (&EnvironmentLoader{}).Load(s))
+	require.Equal(t, "old", s.Postgres.Database)
+
+	t.Setenv("ALIASSERVER_POSTGRES_DATABASE", "new")
+
+	err := (&EnvironmentLoader{}).Load(&AliasServer{})
+	require.EqualError(t, err, "multiconfig: environment: field 'Postgres.Database': both ALIASSERVER_POSTGRES_DATABASE and ALIASSERVER_PG_DBNAME are set")
+
+	s = &AliasServer{}
+	require.NoError(t, (&EnvironmentLoader{AliasPolicy: AliasPreferName}).Load(s))
+	require.Equal(t, "new", s.Postgres.Database)
+
+	s = &AliasServer{}
+	require.NoError(t, (&EnvironmentLoader{AliasPolicy: AliasPreferAlias}).Load(s))
+	require.Equal(t, "old", s.Postgres.Database)
+}
+
+func TestAliasesFlag(t *testing.T) {
+	s := &AliasServer{}
+	f := &FlagLoader{Args: []string{"-pg-db", "old", "-postgres-port", "5432"}}
+	require.NoError(t, f.Load(s))
+	require.Equal(t, "old", s.Postgres.Database)
+	require.Equal(t, 5432, s.Postgres.Port)
+
+	args := []string{"-postgres-dbname", "old", "-postgres-database", "new"}
+
+	f = &FlagLoader{Args: args}
+	require.EqualError(t, f.Load(&AliasServer{}), "multiconfig: flags: field 'Postgres.Database': both -postgres-database and -postgres-dbname are set")
+
+	s = &AliasServer{}
+	f = &FlagLoader{Args: args, AliasPolicy: AliasPreferName}
+	require.NoError(t, f.Load(s))
+	require.Equal(t, "new", s.Postgres.Database)
+
+	s = &AliasServer{}
+	f = &FlagLoader{Args: args, AliasPolicy: AliasPreferAlias}
+	require.NoError(t, f.Load(s))
+	require.Equal(t, "old", s.Postgres.Database)
+
+	// aliases are not documented
+	var buf strings.Builder
+	require.NoError(t, f.WriteUsage(&buf, s))
+	require.NotContains(t, buf.String(), "dbname")
+}
+
+func TestAliasesFileCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"config.json", `{
+  "Name": "koding",
+  "pg": {
+    "db": "configdb",
+    "Port": "port",
+    "Foo": true
+  },
+  "Bar": 1
+}`, `:5: json: cannot unmarshal string into Go struct field .Postgres.Port of type int`},
+		{"config.toml", `Name = "koding"
+Bar = 1
+
+[pg]
+db = "configdb"
+Foo = true
+`, `:2: unknown key "Bar"
+PATH:6: unknown key "pg.Foo"`},
+		{"config.yaml", `name: koding
+bar: 1
+pg:
+  db: configdb
+  foo: true
+  port: port
+`, `:2: line 2: field bar not found in type multiconfig.AliasServer
+PATH:5: line 5: field foo not found in type struct { Database string "aliases:\"DBName,DB\""; Port int }
+PATH:6: line 6: cannot unmarshal !!str ` + "`port`" + ` into int`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeConfig(t, test.name, test.data)
+			err := CheckFile(path, &AliasServer{})
+			require.EqualError(t, err, path+strings.ReplaceAll(test.expected, "PATH", path))
+		})
+	}
+}
+
+func TestAliasesFileUnknownKeys(t *testing.T) {
+	data := `{
+  "Name": "koding",
+  "pg": {
+    "db": "configdb",
+    "Foo": true
+  },
+  "Bar": 1
+}`
+
+	err := (&JSONLoader{Reader: strings.NewReader(data), Strict: true}).Load(&AliasServer{})
+
+	var unknownErr *UnknownKeysError
+	require.ErrorAs(t, err, &unknownErr)
+	require.Equal(t, []UnknownKey{{Key: "pg.Foo", Line: 5}, {Key: "Bar", Line: 7}}, unknownErr.Keys)
+}
@@ -0,0 +1,160 @@
+package multiconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Problem is an issue found in a config file by CheckFile.
+type Problem struct {
+	// Line is the line of the problem in the file, or 0 if it's unknown
+	// (i.e: for a missing required field).
+	Line int
+
+	// Err describes the problem
+	Err error
+}
+
+// CheckError lists every problem found in a config file by CheckFile.
+type CheckError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *CheckError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		if p.Line > 0 {
+			lines = append(lines, fmt.Sprintf("%s:%d: %s", e.Path, p.Line, p.Err))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %s", e.Path, p.Err))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// CheckFile loads the config file at path into the struct s without starting
+// anything, so config changes can be checked before they are deployed (i.e:
+// in CI). The file is loaded on top of the default values with the file
+// loader matching its extension in strict mode, and the result is validated
+// with a RequiredValidator, a ConstraintValidator and the given validators.
+// Every problem found is reported in a *CheckError, with its line in the file
+// when it's known.
+func CheckFile(path string, s any, validators ...Validator) error {
+	format, ok := fileFormat(path)
+	if !ok {
+		return fmt.Errorf("multiconfig: unsupported config file %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := (&TagLoader{}).Load(s); err != nil {
+		return err
+	}
+
+	check := &CheckError{Path: path}
+
+	loader := newFileLoader(format, "", bytes.NewReader(data), true)
+	if err := loader.Load(s); err != nil {
+		fatal := check.addLoadError(data, err)
+		if fatal {
+			return check
+		}
+	}
+
+	for _, err := range (&RequiredValidator{}).validate(s) {
+		check.Problems = append(check.Problems, Problem{Err: err})
+	}
+
+	for _, err := range (&ConstraintValidator{}).validate(s) {
+		check.Problems = append(check.Problems, Problem{Err: err})
+	}
+
+	for _, validator := range validators {
+		err := validator.Validate(s)
+		if err == nil {
+			continue
+		}
+
+		// errors.Join is used to report several errors at once
+		if errs, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range errs.Unwrap() {
+				check.Problems = append(check.Problems, Problem{Err: err})
+			}
+			continue
+		}
+
+		check.Problems = append(check.Problems, Problem{Err: err})
+	}
+
+	if len(check.Problems) > 0 {
+		return check
+	}
+
+	return nil
+}
+
+var errorLine = regexp.MustCompile(`^(?:toml: |yaml: )?line (\d+)`)
+
+// addLoadError adds the problems described by the error of a file loader. It
+// returns true if the file could not be loaded at all.
+func (e *CheckError) addLoadError(data []byte, err error) bool {
+	// the problems are reported with the path of the file already
+	var loadErr *LoadError
+	if errors.As(err, &loadErr) {
+		err = loadErr.Err
+	}
+
+	var (
+		unknownErr *UnknownKeysError
+		yamlErr    *yaml.TypeError
+	)
+
+	switch {
+	case errors.As(err, &unknownErr):
+		for _, key := range unknownErr.Keys {
+			e.Problems = append(e.Problems, Problem{
+				Line: key.Line,
+				Err:  fmt.Errorf("unknown key %q", key.Key),
+			})
+		}
+
+		return false
+	case errors.As(err, &yamlErr):
+		for _, msg := range yamlErr.Errors {
+			e.Problems = append(e.Problems, Problem{
+				Line: messageLine(msg),
+				Err:  errors.New(msg),
+			})
+		}
+
+		return false
+	default:
+		e.Problems = append(e.Problems, Problem{Line: fileErrorLine(data, err), Err: err})
+	}
+
+	return true
+}
+
+// messageLine returns the line mentioned at the beginning of an error message
+// of the toml and yaml decoders, or 0.
+func messageLine(msg string) int {
+	m := errorLine.FindStringSubmatch(msg)
+	if m == nil {
+		return 0
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	return line
+}
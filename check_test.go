@@ -0,0 +1,106 @@
+package multiconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestCheckFile(t *testing.T) {
+	require.NoError(t, CheckFile(testJSON, &Server{}))
+	require.NoError(t, CheckFile(testYAML, &Server{}))
+
+	// the epochs are defined in the Postgres table of the toml file, so
+	// they are ignored and the default values are used instead
+	err := CheckFile(testTOML, &Server{})
+	expected := `testdata/config.toml:14: unknown key "Postgres.Epoch"
+testdata/config.toml:15: unknown key "Postgres.Epoch32"
+testdata/config.toml:16: unknown key "Postgres.Epoch64"`
+	require.EqualError(t, err, expected)
+}
+
+func TestCheckFileTOML(t *testing.T) {
+	path := writeConfig(t, "config.toml", `Name = "koding"
+Nmae = "typo"
+
+[Postgres]
+Port = 5432
+Hsts = ["localhost"]
+
+[Unknown]
+Foo = 1
+`)
+
+	err := CheckFile(path, &Server{})
+	var checkErr *CheckError
+	require.ErrorAs(t, err, &checkErr)
+
+	expected := path + `:2: unknown key "Nmae"
+` + path + `:6: unknown key "Postgres.Hsts"
+` + path + `:8: unknown key "Unknown"
+` + path + `: multiconfig: field 'Postgres.Hosts' is required`
+	require.Equal(t, expected, err.Error())
+}
+
+func TestCheckFileJSON(t *testing.T) {
+	path := writeConfig(t, "config.json", `{
+  "Name": "koding",
+  "Postgres": {
+    "Port": 5432,
+    "Hosts": ["localhost"],
+    "Foo": true
+  }
+}`)
+
+	errValidator := validatorFunc(func(s any) error {
+		return errors.Join(errors.New("first"), errors.New("second"))
+	})
+
+	err := CheckFile(path, &Server{}, errValidator)
+	var checkErr *CheckError
+	require.ErrorAs(t, err, &checkErr)
+	require.Equal(t, []Problem{
+		{Line: 6, Err: errors.New(`unknown key "Postgres.Foo"`)},
+		{Err: errors.New("first")},
+		{Err: errors.New("second")},
+	}, checkErr.Problems)
+
+	path = writeConfig(t, "config.json", "{\n  \"Port\": \"6060\"\n}")
+	require.ErrorAs(t, CheckFile(path, &Server{}), &checkErr)
+	require.Len(t, checkErr.Problems, 1)
+	require.Equal(t, 2, checkErr.Problems[0].Line)
+}
+
+func TestCheckFileYAML(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `name: koding
+port: abc
+postgres:
+  port: 5432
+  hosts: [localhost]
+  foo: bar
+`)
+
+	err := CheckFile(path, &Server{})
+	var checkErr *CheckError
+	require.ErrorAs(t, err, &checkErr)
+	require.Len(t, checkErr.Problems, 2)
+	require.Equal(t, 2, checkErr.Problems[0].Line)
+	require.Equal(t, 6, checkErr.Problems[1].Line)
+
+	require.Error(t, CheckFile("config.ini", &Server{}))
+}
+
+type validatorFunc func(s any) error
+
+func (f validatorFunc) Validate(s any) error { return f(s) }
@@ -0,0 +1,207 @@
+package multiconfig
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Shell is a shell supported by DocGenerator.Completion.
+type Shell string
+
+const (
+	// ShellBash generates a script to be sourced by bash
+	ShellBash Shell = "bash"
+
+	// ShellZsh generates a script to be installed in the zsh $fpath
+	ShellZsh Shell = "zsh"
+
+	// ShellFish generates a script to be sourced by fish
+	ShellFish Shell = "fish"
+)
+
+// completion describes how the value of a flag is completed.
+type completion struct {
+	flag  string
+	usage string
+
+	// bool flags don't take a value
+	bool bool
+
+	// values holds the values of a "oneof" tag
+	values []string
+
+	// path is "file" or "dir" for fields tagged with `path:"file"` or
+	// `path:"dir"`
+	path string
+}
+
+func (d *DocGenerator) completions(s any) ([]*completion, error) {
+	list, err := d.settings(s)
+	if err != nil {
+		return nil, err
+	}
+
+	completions := []*completion{}
+	for _, st := range list {
+		if st.Flag == "" || st.Hidden {
+			continue
+		}
+
+		usage, _, _ := strings.Cut(st.Usage, "\n")
+		typ := st.field.Field.Type
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		completions = append(completions, &completion{
+			flag:   st.Flag,
+			usage:  usage,
+			bool:   typ.Kind() == reflect.Bool,
+			values: strings.Fields(st.field.Field.Tag.Get("oneof")),
+			path:   st.field.Field.Tag.Get("path"),
+		})
+	}
+
+	return completions, nil
+}
+
+// Completion writes a completion script for the given shell of the flags the
+// FlagLoader generates for the config struct s. Values of fields with a
+// "oneof" tag are completed from its list, and fields tagged with
+// `path:"file"` or `path:"dir"` complete file or directory names. The
+// script completes the program named by the Name field.
+func (d *DocGenerator) Completion(s any, shell Shell, w io.Writer) error {
+	completions, err := d.completions(s)
+	if err != nil {
+		return err
+	}
+
+	var script string
+	switch shell {
+	case ShellBash:
+		script = bashCompletion(d.name(), completions)
+	case ShellZsh:
+		script = zshCompletion(d.name(), completions)
+	case ShellFish:
+		script = fishCompletion(d.name(), completions)
+	default:
+		return fmt.Errorf("multiconfig: unsupported shell %q", shell)
+	}
+
+	_, err = io.WriteString(w, script)
+	return err
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func bashCompletion(name string, completions []*completion) string {
+	fn := "_" + nonIdentifier.ReplaceAllString(name, "_")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur prev\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n\n")
+	b.WriteString("    case \"$prev\" in\n")
+
+	flags := make([]string, 0, len(completions))
+	for _, c := range completions {
+		flags = append(flags, "-"+c.flag)
+		if c.bool {
+			continue
+		}
+
+		fmt.Fprintf(&b, "        -%s|--%s)\n", c.flag, c.flag)
+		switch {
+		case len(c.values) > 0:
+			fmt.Fprintf(&b, "            COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(c.values, " ")))
+		case c.path == "file":
+			b.WriteString("            COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		case c.path == "dir":
+			b.WriteString("            COMPREPLY=($(compgen -d -- \"$cur\"))\n")
+		default:
+			b.WriteString("            COMPREPLY=()\n")
+		}
+		b.WriteString("            return 0\n")
+		b.WriteString("            ;;\n")
+	}
+
+	b.WriteString("    esac\n\n")
+	fmt.Fprintf(&b, "    COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(flags, " ")))
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, shellQuote(name))
+
+	return b.String()
+}
+
+func zshCompletion(name string, completions []*completion) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n", name)
+	b.WriteString("_arguments")
+
+	for _, c := range completions {
+		usage := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(c.usage)
+		spec := "-" + c.flag + "[" + usage + "]"
+
+		if !c.bool {
+			switch {
+			case len(c.values) > 0:
+				spec += ":" + c.flag + ":(" + strings.Join(c.values, " ") + ")"
+			case c.path == "file":
+				spec += ":" + c.flag + ":_files"
+			case c.path == "dir":
+				spec += ":" + c.flag + ":_files -/"
+			default:
+				spec += ":" + c.flag + ": "
+			}
+		}
+
+		fmt.Fprintf(&b, " \\\n    %s", shellQuote(spec))
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+func fishCompletion(name string, completions []*completion) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n", name)
+
+	for _, c := range completions {
+		line := fmt.Sprintf("complete -c %s -o %s", shellQuote(name), c.flag)
+		if c.usage != "" {
+			line += " -d " + fishQuote(c.usage)
+		}
+
+		if !c.bool {
+			switch {
+			case len(c.values) > 0:
+				line += " -x -a " + fishQuote(strings.Join(c.values, " "))
+			case c.path == "file":
+				line += " -r -F"
+			case c.path == "dir":
+				line += " -x -a '(__fish_complete_directories)'"
+			default:
+				line += " -x"
+			}
+		}
+
+		b.WriteString(line + "\n")
+	}
+
+	return b.String()
+}
+
+// shellQuote quotes s for POSIX shells, zsh included.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish, which supports escaping in single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,68 @@
+package multiconfig
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type CompletionServer struct {
+	Name    string `flagUsage:"Name of the server"`
+	Level   string `oneof:"debug info error" flagUsage:"Log level"`
+	Config  string `path:"file"`
+	Data    string `path:"dir"`
+	Enabled bool
+}
+
+func TestCompletionBash(t *testing.T) {
+	d := &DocGenerator{Name: "my-app"}
+
+	var buf bytes.Buffer
+	require.NoError(t, d.Completion(&CompletionServer{}, ShellBash, &buf))
+
+	out := buf.String()
+	require.Contains(t, out, "_my_app() {\n")
+	require.Contains(t, out, "        -level|--level)\n            COMPREPLY=($(compgen -W 'debug info error' -- \"$cur\"))\n")
+	require.Contains(t, out, "        -config|--config)\n            COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	require.Contains(t, out, "        -data|--data)\n            COMPREPLY=($(compgen -d -- \"$cur\"))\n")
+	require.NotContains(t, out, "-enabled|--enabled")
+	require.Contains(t, out, "COMPREPLY=($(compgen -W '-name -level -config -data -enabled' -- \"$cur\"))\n")
+	require.Contains(t, out, "complete -F _my_app 'my-app'\n")
+}
+
+func TestCompletionZsh(t *testing.T) {
+	d := &DocGenerator{Name: "app"}
+
+	var buf bytes.Buffer
+	require.NoError(t, d.Completion(&CompletionServer{}, ShellZsh, &buf))
+
+	expected := `#compdef app
+
+_arguments \
+    '-name[Name of the server]:name: ' \
+    '-level[Log level]:level:(debug info error)' \
+    '-config[Change value of Config.]:config:_files' \
+    '-data[Change value of Data.]:data:_files -/' \
+    '-enabled[Change value of Enabled.]'
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestCompletionFish(t *testing.T) {
+	d := &DocGenerator{Name: "app"}
+
+	var buf bytes.Buffer
+	require.NoError(t, d.Completion(&CompletionServer{}, ShellFish, &buf))
+
+	expected := `# fish completion for app
+complete -c 'app' -o name -d 'Name of the server' -x
+complete -c 'app' -o level -d 'Log level' -x -a 'debug info error'
+complete -c 'app' -o config -d 'Change value of Config.' -r -F
+complete -c 'app' -o data -d 'Change value of Data.' -x -a '(__fish_complete_directories)'
+complete -c 'app' -o enabled -d 'Change value of Enabled.'
+`
+	require.Equal(t, expected, buf.String())
+
+	require.Error(t, d.Completion(&CompletionServer{}, Shell("csh"), &buf))
+}
@@ -0,0 +1,138 @@
+package multiconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConsulBackend is a KVBackend reading the KV store of Consul with its HTTP
+// API. It implements KVWatcher with blocking queries.
+type ConsulBackend struct {
+	// Address is the address of the Consul agent, i.e:
+	// "http://127.0.0.1:8500". The scheme is http if it's omitted. By
+	// default the CONSUL_HTTP_ADDR environment variable is used, or
+	// "127.0.0.1:8500" if it's not set.
+	Address string
+
+	// Token is the ACL token sent with the requests. By default the
+	// CONSUL_HTTP_TOKEN environment variable is used.
+	Token string
+
+	// Datacenter is the datacenter to query. By default it's the one of
+	// the agent.
+	Datacenter string
+
+	// WaitTime is the maximum duration of a blocking query. The default is
+	// 5 minutes.
+	WaitTime time.Duration
+
+	// Client is the HTTP client used for the requests. By default
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+// consulPair is a key/value pair as returned by the KV endpoint.
+type consulPair struct {
+	Key   string
+	Value []byte
+}
+
+// List returns the values of the keys starting with prefix.
+func (c *ConsulBackend) List(ctx context.Context, prefix string) (map[string]string, error) {
+	pairs, _, err := c.Watch(ctx, prefix, 0)
+	return pairs, err
+}
+
+// Watch returns the values of the keys starting with prefix once they change
+// after index, see KVWatcher.
+func (c *ConsulBackend) Watch(ctx context.Context, prefix string, index uint64) (map[string]string, uint64, error) {
+	query := url.Values{"recurse": {"true"}}
+	if c.Datacenter != "" {
+		query.Set("dc", c.Datacenter)
+	}
+
+	if index > 0 {
+		wait := c.WaitTime
+		if wait == 0 {
+			wait = 5 * time.Minute
+		}
+
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%dms", wait.Milliseconds()))
+	}
+
+	u := c.address() + "/v1/kv/" + strings.TrimPrefix(prefix, "/") + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	token := c.Token
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+
+	if token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// no key has the prefix
+		return map[string]string{}, next, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("consul: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var entries []consulPair
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("consul: %w", err)
+	}
+
+	pairs := make(map[string]string, len(entries))
+	for _, e := range entries {
+		pairs[e.Key] = string(e.Value)
+	}
+
+	return pairs, next, nil
+}
+
+func (c *ConsulBackend) address() string {
+	addr := c.Address
+	if addr == "" {
+		addr = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+
+	if addr == "" {
+		addr = "127.0.0.1:8500"
+	}
+
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+
+	return strings.TrimSuffix(addr, "/")
+}
@@ -0,0 +1,169 @@
+package multiconfig
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsul is a stand-in for the KV endpoint of the Consul HTTP API,
+// supporting blocking queries.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	pairs   map[string]string
+	changed chan struct{}
+
+	// requests holds the query of each request
+	requests []string
+}
+
+func newFakeConsul(pairs map[string]string) (*fakeConsul, *httptest.Server) {
+	c := &fakeConsul{index: 1, pairs: pairs, changed: make(chan struct{})}
+	return c, httptest.NewServer(c)
+}
+
+func (c *fakeConsul) set(key, val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pairs[key] = val
+	c.index++
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "t0k3n" {
+		http.Error(w, "ACL not found", http.StatusForbidden)
+		return
+	}
+
+	c.mu.Lock()
+	c.requests = append(c.requests, r.URL.RawQuery)
+	index, changed := c.index, c.changed
+	c.mu.Unlock()
+
+	if i := r.URL.Query().Get("index"); i != "" && i == strconv.FormatUint(index, 10) {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	entries := []consulPair{}
+	for key, val := range c.pairs {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, consulPair{Key: key, Value: []byte(val)})
+		}
+	}
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(c.index, 10))
+	if len(entries) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(entries)
+}
+
+func TestConsulBackend(t *testing.T) {
+	_, srv := newFakeConsul(map[string]string{
+		"app/name":          "koding",
+		"app/postgres/port": "5432",
+	})
+	defer srv.Close()
+
+	b := &ConsulBackend{Address: srv.URL, Token: "t0k3n", Datacenter: "dc1"}
+
+	pairs, err := b.List(context.Background(), "app/")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"app/name": "koding", "app/postgres/port": "5432"}, pairs)
+
+	pairs, err = b.List(context.Background(), "other/")
+	require.NoError(t, err)
+	require.Empty(t, pairs)
+
+	s := &Server{}
+	require.NoError(t, (&KVLoader{Backend: b, Prefix: "app"}).Load(s))
+	require.Equal(t, "koding", s.Name)
+	require.Equal(t, uint16(5432), s.Postgres.Port)
+
+	t.Setenv("CONSUL_HTTP_ADDR", strings.TrimPrefix(srv.URL, "http://"))
+	t.Setenv("CONSUL_HTTP_TOKEN", "t0k3n")
+	pairs, err = (&ConsulBackend{}).List(context.Background(), "app/")
+	require.NoError(t, err)
+	require.Len(t, pairs, 2)
+}
+
+func TestConsulBackendErrors(t *testing.T) {
+	_, srv := newFakeConsul(map[string]string{})
+	defer srv.Close()
+
+	_, err := (&ConsulBackend{Address: srv.URL}).List(context.Background(), "app/")
+	require.EqualError(t, err, "consul: 403 Forbidden: ACL not found")
+
+	err = (&KVLoader{Backend: &ConsulBackend{Address: srv.URL}, Prefix: "app"}).Load(&Server{})
+	require.EqualError(t, err, "multiconfig: kv store app: consul: 403 Forbidden: ACL not found")
+}
+
+func TestKVLoaderWatch(t *testing.T) {
+	consul, srv := newFakeConsul(map[string]string{"app/name": "koding"})
+	defer srv.Close()
+
+	k := &KVLoader{
+		Backend: &ConsulBackend{Address: srv.URL, Token: "t0k3n", WaitTime: time.Second},
+		Prefix:  "app",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- k.Watch(ctx, &Server{}, func() { changes <- struct{}{} })
+	}()
+
+	// wait for the blocking query
+	require.Eventually(t, func() bool {
+		consul.mu.Lock()
+		defer consul.mu.Unlock()
+		return len(consul.requests) >= 2
+	}, time.Second, time.Millisecond)
+
+	consul.set("app/name", "gopher")
+
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("no change notified")
+	}
+
+	s := &Server{}
+	require.NoError(t, k.Load(s))
+	require.Equal(t, "gopher", s.Name)
+
+	consul.mu.Lock()
+	require.Equal(t, "recurse=true", consul.requests[0])
+	require.Equal(t, "index=1&recurse=true&wait=1000ms", consul.requests[1])
+	consul.mu.Unlock()
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
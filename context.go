@@ -0,0 +1,29 @@
+package multiconfig
+
+import "context"
+
+// ContextLoader is implemented by the loaders which can be cancelled, i.e:
+// the ones reading remote sources. Loaders which don't implement it are
+// still supported by the context aware loaders, which check the context
+// before calling them.
+type ContextLoader interface {
+	Loader
+
+	// LoadContext loads the source into the config defined by struct s. It
+	// returns ctx.Err() if ctx is done before the loading is complete.
+	LoadContext(ctx context.Context, s any) error
+}
+
+// loadContext loads s with l, using LoadContext if l implements
+// ContextLoader. Other loaders are not called once ctx is done.
+func loadContext(ctx context.Context, l Loader, s any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if cl, ok := l.(ContextLoader); ok {
+		return cl.LoadContext(ctx, s)
+	}
+
+	return l.Load(s)
+}
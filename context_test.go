@@ -0,0 +1,84 @@
+package multiconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slowLoader blocks until its context is done.
+type slowLoader struct{}
+
+func (slowLoader) Load(s any) error {
+	return slowLoader{}.LoadContext(context.Background(), s)
+}
+
+func (slowLoader) LoadContext(ctx context.Context, s any) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// contextResolver resolves references with a value of its context.
+type contextResolver struct{}
+
+type contextKey struct{}
+
+func (contextResolver) Resolve(ref string) (string, error) {
+	return contextResolver{}.ResolveContext(context.Background(), ref)
+}
+
+func (contextResolver) ResolveContext(ctx context.Context, ref string) (string, error) {
+	if v, ok := ctx.Value(contextKey{}).(string); ok {
+		return v + "-" + ref, nil
+	}
+
+	return ref, nil
+}
+
+func TestLoadContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := &Server{}
+	err := MultiLoader(&TagLoader{}).(ContextLoader).LoadContext(ctx, s)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, s.Port)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	d := &DefaultLoader{Loader: MultiLoader(&TagLoader{}, slowLoader{}, &EnvironmentLoader{})}
+	err = d.LoadContext(ctx, s)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 6060, s.Port)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	p := Provenance{}
+	err = TrackProvenance(p, &TagLoader{}, slowLoader{}).(ContextLoader).LoadContext(ctx, &Server{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, "default tag", p["Port"])
+}
+
+func TestSecretLoaderContext(t *testing.T) {
+	l := &SecretLoader{Resolvers: map[string]SecretResolver{"ctx": contextResolver{}}}
+
+	s := &SecretsConfig{Password: "ctx://password"}
+	ctx := context.WithValue(context.Background(), contextKey{}, "value")
+	require.NoError(t, l.LoadContext(ctx, s))
+	require.Equal(t, "value-password", s.Password)
+
+	s = &SecretsConfig{Password: "ctx://password"}
+	require.NoError(t, l.Load(s))
+	require.Equal(t, "password", s.Password)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	s = &SecretsConfig{Password: "ctx://password"}
+	require.ErrorIs(t, l.LoadContext(canceled, s), context.Canceled)
+	require.Equal(t, "ctx://password", s.Password)
+}
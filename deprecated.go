@@ -0,0 +1,81 @@
+package multiconfig
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Deprecation describes a field tagged with `deprecated:"message"` which is
+// set by a source, see DefaultLoader.OnDeprecated.
+type Deprecation struct {
+	// Field is the dotted path of the field, i.e: "Postgres.DBName"
+	Field string
+
+	// Message is the value of the deprecated tag, i.e: "use
+	// Postgres.Database instead"
+	Message string
+
+	// Source is the source which set the field, if it's known
+	Source string
+}
+
+func (d Deprecation) String() string {
+	msg := fmt.Sprintf("field '%s' is deprecated", d.Field)
+	if d.Source != "" {
+		msg += " (set by " + d.Source + ")"
+	}
+
+	if d.Message != "" {
+		msg += ": " + d.Message
+	}
+
+	return msg
+}
+
+// deprecations returns the deprecated fields of s which are set. If the
+// provenance of the fields is known, fields set by default values are
+// ignored, otherwise every field which doesn't hold its zero value or the
+// value of its default tag is reported.
+func deprecations(s any, p Provenance) []Deprecation {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	var defaults map[string]any
+	if p == nil {
+		d := reflect.New(v.Elem().Type())
+		if err := (&TagLoader{}).Load(d.Interface()); err == nil {
+			defaults = snapshot(d.Interface())
+		}
+	}
+
+	var list []Deprecation
+	for _, field := range leafFields(configFields(v, nil)) {
+		msg, ok := field.Field.Tag.Lookup("deprecated")
+		if !ok {
+			continue
+		}
+
+		source, set := p[field.Name()]
+		if p == nil {
+			set = !field.Value.IsZero() &&
+				!reflect.DeepEqual(defaults[field.Name()], field.Value.Interface())
+		}
+
+		switch source {
+		case describeLoader(&TagLoader{}), describeLoader(&InterfaceLoader{}):
+			set = false
+		}
+
+		if set {
+			list = append(list, Deprecation{
+				Field:   field.Name(),
+				Message: msg,
+				Source:  source,
+			})
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,97 @@
+package multiconfig
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type DeprecatedServer struct {
+	Name     string
+	Postgres struct {
+		Hosts  []string
+		DBName string `default:"configdb" deprecated:"use Postgres.Database instead"`
+	}
+	Debug bool `hidden:"true"`
+}
+
+func TestDeprecated(t *testing.T) {
+	var deprecations []Deprecation
+
+	d := New()
+	d.OnDeprecated = func(dep Deprecation) {
+		deprecations = append(deprecations, dep)
+	}
+
+	// set by the default tag only
+	require.NoError(t, d.Load(&DeprecatedServer{}))
+	require.Empty(t, deprecations)
+
+	t.Setenv("DEPRECATEDSERVER_POSTGRES_DBNAME", "mydb")
+
+	s := &DeprecatedServer{}
+	require.NoError(t, d.Load(s))
+	require.Equal(t, "mydb", s.Postgres.DBName)
+	require.Equal(t, []Deprecation{{
+		Field:   "Postgres.DBName",
+		Message: "use Postgres.Database instead",
+		Source:  "environment",
+	}}, deprecations)
+	require.Equal(t, "field 'Postgres.DBName' is deprecated (set by environment): use Postgres.Database instead", deprecations[0].String())
+
+	// without provenance
+	deprecations = nil
+	d = &DefaultLoader{
+		Loader:       MultiLoader(&TagLoader{}, &EnvironmentLoader{}),
+		OnDeprecated: d.OnDeprecated,
+	}
+	require.NoError(t, d.Load(&DeprecatedServer{}))
+	require.Len(t, deprecations, 1)
+	require.Empty(t, deprecations[0].Source)
+}
+
+func TestDeprecatedWarning(t *testing.T) {
+	t.Setenv("DEPRECATEDSERVER_POSTGRES_DBNAME", "mydb")
+
+	var buf bytes.Buffer
+	d := New()
+	d.ErrorOutput = &buf
+	require.NoError(t, d.Load(&DeprecatedServer{}))
+	require.Equal(t, "multiconfig: warning: field 'Postgres.DBName' is deprecated (set by environment): use Postgres.Database instead\n", buf.String())
+
+	// the logger is preferred
+	buf.Reset()
+	logger, logs := newTestLogger()
+	d.Logger = logger
+	require.NoError(t, d.Load(&DeprecatedServer{}))
+	require.Empty(t, buf.String())
+	require.Contains(t, logs.String(), `level=WARN msg="deprecated field set" field=Postgres.DBName source=environment message="use Postgres.Database instead"`)
+}
+
+func TestHidden(t *testing.T) {
+	t.Setenv("DEPRECATEDSERVER_DEBUG", "true")
+
+	var buf bytes.Buffer
+	f := &FlagLoader{Output: &buf, Args: []string{"-name", "koding"}}
+
+	s := &DeprecatedServer{}
+	require.NoError(t, MultiLoader(&EnvironmentLoader{}, f).Load(s))
+	require.True(t, s.Debug)
+
+	require.NoError(t, f.WriteUsage(&buf, s))
+	require.Contains(t, buf.String(), "-postgres-dbname string (deprecated: use Postgres.Database instead)")
+	require.NotContains(t, buf.String(), "debug")
+
+	f.Args = []string{"-debug=false"}
+	require.NoError(t, f.Load(s))
+	require.False(t, s.Debug)
+
+	var envs []string
+	(&EnvironmentLoader{}).visitEnvs(s, func(_ []string, name string, hidden bool) {
+		if !hidden {
+			envs = append(envs, name)
+		}
+	})
+	require.Equal(t, []string{"DEPRECATEDSERVER_NAME", "DEPRECATEDSERVER_POSTGRES_DBNAME", "DEPRECATEDSERVER_POSTGRES_HOSTS"}, envs)
+}
@@ -0,0 +1,164 @@
+package multiconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change is a difference between two configs, see Diff.
+type Change struct {
+	// Path is the dotted path of the field, the same way validators name
+	// fields in their errors, i.e: "Postgres.Port". Elements of slices and
+	// maps are named by their index or key, i.e: "Users[1]" or
+	// "Labels[env]".
+	Path string
+
+	// Old and New are the values before and after the change. Old is nil
+	// for an added element and New is nil for a removed one. The values of
+	// secrets are redacted.
+	Old, New any
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Diff returns the changes between the configs old and new, which must be
+// structs, or pointers to structs, of the same type. Nested structs are
+// compared field by field, slices element by element and maps key by key.
+// The changes are ordered like the fields of the struct.
+func Diff(old, new any) ([]Change, error) {
+	o, n := reflect.ValueOf(old), reflect.ValueOf(new)
+	if !o.IsValid() || !n.IsValid() || o.Type() != n.Type() {
+		return nil, fmt.Errorf("multiconfig: cannot diff %T and %T", old, new)
+	}
+
+	if t := o.Type(); t.Kind() != reflect.Struct && (t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct) {
+		return nil, fmt.Errorf("multiconfig: cannot diff %T, it's not a struct", old)
+	}
+
+	changes := []Change{}
+	diffFields(configFields(o, nil), configFields(n, nil), &changes)
+	return changes, nil
+}
+
+func diffFields(old, new []*configField, changes *[]Change) {
+	for i, field := range old {
+		if field.IsNested() {
+			diffFields(field.Fields, new[i].Fields, changes)
+			continue
+		}
+
+		diffValues(field.Name(), field.Value, new[i].Value, isSecret(field.Field), changes)
+	}
+}
+
+// diffValues appends the changes between the values old and new of path to
+// changes. A value is invalid if it doesn't exist, i.e: an element missing
+// from a slice or a map.
+func diffValues(path string, old, new reflect.Value, secret bool, changes *[]Change) {
+	if equalValues(old, new) {
+		return
+	}
+
+	if old.IsValid() && new.IsValid() {
+		secret = secret || isSecretType(old.Type())
+
+		switch old.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < max(old.Len(), new.Len()); i++ {
+				var o, n reflect.Value
+				if i < old.Len() {
+					o = old.Index(i)
+				}
+				if i < new.Len() {
+					n = new.Index(i)
+				}
+
+				diffValues(fmt.Sprintf("%s[%d]", path, i), o, n, secret, changes)
+			}
+
+			return
+		case reflect.Map:
+			for _, key := range mapKeys(old, new) {
+				diffValues(fmt.Sprintf("%s[%v]", path, key), old.MapIndex(key), new.MapIndex(key), secret, changes)
+			}
+
+			return
+		case reflect.Pointer:
+			if !old.IsNil() && !new.IsNil() {
+				diffValues(path, old.Elem(), new.Elem(), secret, changes)
+				return
+			}
+		case reflect.Struct:
+			if isNestedStruct(old.Type()) {
+				for _, field := range configFields(old, nil) {
+					diffValues(path+"."+field.Name(), field.Value, new.FieldByIndex(field.Field.Index), secret || isSecret(field.Field), changes)
+				}
+
+				return
+			}
+		}
+	}
+
+	*changes = append(*changes, Change{
+		Path: path,
+		Old:  diffValue(old, secret),
+		New:  diffValue(new, secret),
+	})
+}
+
+// equalValues reports whether old and new are deeply equal. Nil and empty
+// slices and maps are equal.
+func equalValues(old, new reflect.Value) bool {
+	if !old.IsValid() || !new.IsValid() {
+		return old.IsValid() == new.IsValid()
+	}
+
+	switch old.Kind() {
+	case reflect.Slice, reflect.Map:
+		if old.Len() == 0 && new.Len() == 0 {
+			return true
+		}
+	}
+
+	return reflect.DeepEqual(old.Interface(), new.Interface())
+}
+
+// diffValue returns the value of a Change.
+func diffValue(v reflect.Value, secret bool) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	return logValue(v.Interface(), secret || isSecretType(v.Type()))
+}
+
+// mapKeys returns the keys of the maps old and new, sorted.
+func mapKeys(old, new reflect.Value) []reflect.Value {
+	seen := map[any]bool{}
+	keys := []reflect.Value{}
+	for _, m := range []reflect.Value{old, new} {
+		for _, key := range m.MapKeys() {
+			if !seen[key.Interface()] {
+				seen[key.Interface()] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+
+	return keys
+}
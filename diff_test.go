@@ -0,0 +1,84 @@
+package multiconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type DiffServer struct {
+	Name     string
+	Port     *int
+	Password Secret
+	Tokens   []string `secret:"true"`
+	Users    []string
+	Labels   map[string]string
+	Backends []Backend
+	Postgres Postgres
+}
+
+type Backend struct {
+	Host string
+	Key  Secret
+}
+
+func TestDiff(t *testing.T) {
+	port := 80
+
+	old := &DiffServer{
+		Name:     "koding",
+		Password: "s3cr3t",
+		Tokens:   []string{"t0k3n"},
+		Users:    []string{"ankara", "istanbul"},
+		Labels:   map[string]string{"env": "dev", "team": "core"},
+		Backends: []Backend{{Host: "a", Key: "k1"}},
+		Postgres: Postgres{Port: 5432, Hosts: []string{"localhost"}},
+	}
+
+	new := &DiffServer{
+		Name:     "koding",
+		Port:     &port,
+		Password: "n3w",
+		Tokens:   []string{"n3w"},
+		Users:    []string{"ankara"},
+		Labels:   map[string]string{"env": "prod", "zone": "ch-gva-2"},
+		Backends: []Backend{{Host: "b", Key: "k2"}},
+		Postgres: Postgres{Port: 5433, Hosts: []string{"localhost"}},
+	}
+
+	changes, err := Diff(old, new)
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Path: "Port", Old: nil, New: 80},
+		{Path: "Password", Old: "******", New: "******"},
+		{Path: "Tokens[0]", Old: "******", New: "******"},
+		{Path: "Users[1]", Old: "istanbul", New: nil},
+		{Path: "Labels[env]", Old: "dev", New: "prod"},
+		{Path: "Labels[team]", Old: "core", New: nil},
+		{Path: "Labels[zone]", Old: nil, New: "ch-gva-2"},
+		{Path: "Backends[0].Host", Old: "a", New: "b"},
+		{Path: "Backends[0].Key", Old: "******", New: "******"},
+		{Path: "Postgres.Port", Old: uint16(5432), New: uint16(5433)},
+	}, changes)
+
+	require.Equal(t, "Postgres.Port: 5432 -> 5433", changes[len(changes)-1].String())
+
+	changes, err = Diff(*old, *old)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	changes, err = Diff(&DiffServer{}, &DiffServer{Users: []string{}, Labels: map[string]string{}})
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
+
+func TestDiffErrors(t *testing.T) {
+	_, err := Diff(&DiffServer{}, &Server{})
+	require.EqualError(t, err, "multiconfig: cannot diff *multiconfig.DiffServer and *multiconfig.Server")
+
+	_, err = Diff("a", "b")
+	require.EqualError(t, err, "multiconfig: cannot diff string, it's not a struct")
+
+	_, err = Diff(nil, &Server{})
+	require.Error(t, err)
+}
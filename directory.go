@@ -0,0 +1,305 @@
+package multiconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/camelcase"
+	"github.com/fatih/structs"
+)
+
+// DirectoryLoader satisfies the loader interface. It loads the configuration
+// from a directory holding one file per field, such as a Kubernetes ConfigMap
+// or Secret mounted as a volume. The file names are generated like the names
+// of the environment variables of the EnvironmentLoader, without the struct
+// name, and are matched in any case with either dots or underscores as
+// separators, i.e: "postgres.port", "POSTGRES_PORT" or "Postgres_Port" for
+// the field Postgres.Port. Leading and trailing whitespace is removed from
+// the values and empty files are ignored, like other files and the hidden
+// files such as the "..data" symlink of Kubernetes.
+type DirectoryLoader struct {
+	// Path is the path of the directory.
+	Path string
+
+	// Prefix prepends the given string to every file name, i.e: "app" for
+	// "app.postgres.port" or "APP_POSTGRES_PORT".
+	Prefix string
+
+	// CamelCase adds a separator for field names in camelcase form. A
+	// fieldname of "AccessKey" would generate the file name "accesskey". If
+	// CamelCase is enabled, the file name will be "access_key" or
+	// "access.key".
+	CamelCase bool
+
+	// AliasPolicy defines what happens when a field is set by both its file
+	// and one of the files generated from its "aliases" tag.
+	AliasPolicy AliasPolicy
+
+	// Interval is the polling interval of Watch. The default is 1 second.
+	Interval time.Duration
+
+	// Logger logs the decisions of the loader at the debug level, with the
+	// values of the files redacted. Nothing is logged if it's nil.
+	Logger *slog.Logger
+}
+
+// dirFile is a file of the directory of a DirectoryLoader.
+type dirFile struct {
+	name  string
+	value string
+}
+
+// Load loads the source into the config defined by struct s
+func (d *DirectoryLoader) Load(s any) error {
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
+	files, err := d.readFiles()
+	if err != nil {
+		return &LoadError{Loader: "directory", Source: d.Path, Err: err}
+	}
+
+	prefix := ""
+	if d.Prefix != "" {
+		prefix = normalizeFileName(d.Prefix)
+	}
+
+	strct := structs.New(s)
+	for key, val := range strct.Map() {
+		if err := d.processField([]string{prefix}, nil, strct.Field(key), key, val, files); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Watch calls onChange every time the content of the directory changes,
+// until ctx is done. The directory is polled every Interval. When it's
+// updated by Kubernetes, which swaps the "..data" symlink to a new
+// directory, onChange is called once the swap is complete. It's meant to
+// reload a Store:
+//
+//	go dir.Watch(ctx, func() { store.Reload() })
+//
+// Watch returns the error of ctx once it's done, or an error if the directory
+// cannot be read.
+func (d *DirectoryLoader) Watch(ctx context.Context, onChange func()) error {
+	interval := d.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	last, err := d.fingerprint()
+	if err != nil {
+		return &LoadError{Loader: "directory", Source: d.Path, Err: err}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := d.fingerprint()
+		if err != nil {
+			return &LoadError{Loader: "directory", Source: d.Path, Err: err}
+		}
+
+		if current == last {
+			continue
+		}
+
+		last = current
+		if d.Logger != nil {
+			d.Logger.Debug("directory changed", "path", d.Path)
+		}
+
+		onChange()
+	}
+}
+
+// fingerprint returns a value which changes with the content of the
+// directory. If the directory is managed by Kubernetes, it's the target of
+// the "..data" symlink, which is atomically swapped on every update, so the
+// files are never read in the middle of an update.
+func (d *DirectoryLoader) fingerprint() (string, error) {
+	if target, err := os.Readlink(filepath.Join(d.Path, "..data")); err == nil {
+		return target, nil
+	}
+
+	files, err := d.readFiles()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(files)) {
+		fmt.Fprintf(h, "%s=%q\n", files[key].name, files[key].value)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// readFiles returns the files of the directory keyed by their normalized
+// name, see normalizeFileName. An error is returned if two files which are
+// not empty have the same normalized name. If the directory is managed by Kubernetes,
+// the files are read from "..data" directly: it's swapped before the
+// symlinks of the added keys are created and those of the removed keys are
+// deleted, so they're out of date in the middle of an update.
+func (d *DirectoryLoader) readFiles() (map[string]dirFile, error) {
+	dir := d.Path
+	if info, err := os.Stat(filepath.Join(d.Path, "..data")); err == nil && info.IsDir() {
+		dir = filepath.Join(d.Path, "..data")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]dirFile{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		// the files of a Kubernetes volume are symlinks, so they're
+		// followed before skipping the directories and the dangling ones
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, value := normalizeFileName(name), strings.TrimSpace(string(data))
+		if prev, ok := files[key]; ok && prev.value != "" {
+			if value == "" {
+				continue
+			}
+
+			return nil, fmt.Errorf("files %s and %s set the same key", prev.name, name)
+		}
+
+		files[key] = dirFile{name: name, value: value}
+	}
+
+	return files, nil
+}
+
+// processField generates the file names of the field like
+// EnvironmentLoader.processField generates the names of its environment
+// variables, and sets the field from the files.
+func (d *DirectoryLoader) processField(prefixes []string, path []string, field *structs.Field, name string, strctMap any, files map[string]dirFile) error {
+	path = append(path[:len(path):len(path)], field.Name())
+
+	names := []string{}
+	for _, prefix := range prefixes {
+		for _, n := range append([]string{name}, fieldAliases(field.Tag("aliases"))...) {
+			names = append(names, d.generateFileName(prefix, n))
+		}
+	}
+
+	switch smap := strctMap.(type) {
+	case map[string]any:
+		for key, val := range smap {
+			if err := d.processField(names, path, field.Field(key), key, val, files); err != nil {
+				return err
+			}
+		}
+	default:
+		// the actual file names are reported when several are set
+		sources := make([]string, len(names))
+		set := make([]bool, len(names))
+		for i, n := range names {
+			f := files[n]
+			sources[i], set[i] = n, f.value != ""
+			if set[i] {
+				sources[i] = f.name
+			}
+		}
+
+		chosen, err := d.AliasPolicy.choose(sources, set)
+		if err != nil {
+			return &LoadError{Loader: "directory", Field: strings.Join(path, "."), Err: err}
+		}
+
+		if chosen < 0 {
+			return nil
+		}
+
+		// the files are redacted as they're usually mounted secrets
+		file := files[names[chosen]]
+		if d.Logger != nil {
+			d.Logger.Debug("directory file found",
+				"file", file.name,
+				"field", strings.Join(path, "."),
+				"value", logValue(file.value, true),
+			)
+		}
+
+		if err := fieldSet(field, file.value); err != nil {
+			return &LoadError{
+				Loader: "directory",
+				Source: filepath.Join(d.Path, file.name),
+				Field:  strings.Join(path, "."),
+				Err:    err,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *DirectoryLoader) generateFileName(prefix string, name string) string {
+	fileName := strings.ToLower(name)
+	if d.CamelCase {
+		fileName = strings.ToLower(strings.Join(camelcase.Split(name), "_"))
+	}
+
+	if prefix == "" {
+		return fileName
+	}
+
+	return prefix + "_" + fileName
+}
+
+// normalizeFileName returns the name of a file in lowercase with dots and
+// dashes replaced by underscores, so "postgres.port" and "POSTGRES_PORT"
+// match the same field.
+func normalizeFileName(name string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToLower(name))
+}
@@ -0,0 +1,218 @@
+package multiconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeVolume writes the files to dir the way Kubernetes updates a ConfigMap
+// volume: the files are written to a new hidden directory, the "..data"
+// symlink is atomically swapped to it, and each file is a symlink through
+// "..data".
+func writeVolume(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+
+	data := filepath.Join(dir, ".."+version)
+	require.NoError(t, os.Mkdir(data, 0o755))
+	for name, val := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(data, name), []byte(val), 0o644))
+	}
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	require.NoError(t, os.Symlink(".."+version, tmp))
+	require.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+
+	for name := range files {
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			require.NoError(t, os.Symlink(filepath.Join("..data", name), link))
+		}
+	}
+}
+
+func TestDirectoryLoader(t *testing.T) {
+	dir := t.TempDir()
+	writeVolume(t, dir, "v1", map[string]string{
+		"name":           "koding\n",
+		"POSTGRES_PORT":  "5432",
+		"postgres.hosts": "db1,db2",
+		"Users":          "ankara,istanbul",
+		"enabled":        "",
+		"unknown":        "ignored",
+	})
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "id"), 0o755))
+
+	s := &Server{}
+	require.NoError(t, (&DirectoryLoader{Path: dir}).Load(s))
+	require.Equal(t, "koding", s.Name)
+	require.Equal(t, uint16(5432), s.Postgres.Port)
+	require.Equal(t, []string{"db1", "db2"}, s.Postgres.Hosts)
+	require.Equal(t, []string{"ankara", "istanbul"}, s.Users)
+	require.False(t, s.Enabled)
+	require.Zero(t, s.ID)
+}
+
+func TestDirectoryLoaderPrefix(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "APP_ACCESS_KEY"), []byte("key"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.availability-ratio"), []byte("0.5"), 0o644))
+
+	s := &CamelCaseServer{}
+	require.NoError(t, (&DirectoryLoader{Path: dir, Prefix: "app", CamelCase: true}).Load(s))
+	require.Equal(t, &CamelCaseServer{AccessKey: "key", AvailabilityRatio: 0.5}, s)
+}
+
+func TestDirectoryLoaderAliases(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pg.db"), []byte("configdb"), 0o644))
+
+	s := &AliasServer{}
+	require.NoError(t, (&DirectoryLoader{Path: dir}).Load(s))
+	require.Equal(t, "configdb", s.Postgres.Database)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "POSTGRES_DATABASE"), []byte("other"), 0o644))
+	err := (&DirectoryLoader{Path: dir}).Load(&AliasServer{})
+	require.EqualError(t, err, "multiconfig: directory: field 'Postgres.Database': both POSTGRES_DATABASE and pg.db are set")
+}
+
+func TestDirectoryLoaderErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "port"), []byte("port"), 0o644))
+
+	err := (&DirectoryLoader{Path: dir}).Load(&Server{})
+
+	var loadErr *LoadError
+	require.ErrorAs(t, err, &loadErr)
+	require.Equal(t, "directory", loadErr.Loader)
+	require.Equal(t, filepath.Join(dir, "port"), loadErr.Source)
+	require.Equal(t, "Port", loadErr.Field)
+
+	err = (&DirectoryLoader{Path: filepath.Join(dir, "missing")}).Load(&Server{})
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	err = (&DirectoryLoader{Path: dir}).Load(Server{})
+	require.ErrorIs(t, err, ErrNotStructPointer)
+
+	// files with the same normalized name
+	dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "POSTGRES_PORT"), []byte("5432"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "postgres.port"), []byte("5433"), 0o644))
+
+	err = (&DirectoryLoader{Path: dir}).Load(&Server{})
+	require.ErrorAs(t, err, &loadErr)
+	require.Equal(t, dir, loadErr.Source)
+	require.EqualError(t, err, "multiconfig: directory "+dir+": files POSTGRES_PORT and postgres.port set the same key")
+
+	// an empty file is ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "postgres.port"), nil, 0o644))
+	s := &Server{}
+	require.NoError(t, (&DirectoryLoader{Path: dir}).Load(s))
+	require.Equal(t, uint16(5432), s.Postgres.Port)
+}
+
+// watchDirectory starts watching d and returns a channel receiving the
+// changes.
+func watchDirectory(t *testing.T, d *DirectoryLoader) <-chan struct{} {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- d.Watch(ctx, func() { changes <- struct{}{} })
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	// let Watch take its first fingerprint
+	time.Sleep(20 * time.Millisecond)
+	return changes
+}
+
+func TestDirectoryLoaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeVolume(t, dir, "v1", map[string]string{"name": "koding", "port": "4000"})
+
+	d := &DirectoryLoader{Path: dir, Interval: 5 * time.Millisecond}
+	changes := watchDirectory(t, d)
+
+	time.Sleep(20 * time.Millisecond)
+	require.Empty(t, changes)
+
+	writeVolume(t, dir, "v2", map[string]string{"name": "gopher", "port": "4000"})
+
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("no change notified")
+	}
+
+	s := &Server{}
+	require.NoError(t, d.Load(s))
+	require.Equal(t, "gopher", s.Name)
+
+	time.Sleep(20 * time.Millisecond)
+	require.Empty(t, changes, "a swap must be notified once")
+}
+
+func TestDirectoryLoaderWatchRemovedKey(t *testing.T) {
+	dir := t.TempDir()
+	writeVolume(t, dir, "v1", map[string]string{"name": "koding", "port": "4000"})
+
+	d := &DirectoryLoader{Path: dir, Interval: 5 * time.Millisecond}
+	changes := watchDirectory(t, d)
+
+	// the "port" symlink is left dangling, as it is until Kubernetes
+	// deletes it after the swap
+	writeVolume(t, dir, "v2", map[string]string{"name": "gopher", "pass": "s3cr3t"})
+
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("no change notified")
+	}
+
+	s := &Server{}
+	require.NoError(t, d.Load(s))
+	require.Equal(t, "gopher", s.Name)
+	require.Zero(t, s.Port)
+
+	// the keys added by the update are read too
+	p := &struct{ Pass string }{}
+	require.NoError(t, d.Load(p))
+	require.Equal(t, "s3cr3t", p.Pass)
+}
+
+func TestDirectoryLoaderDanglingSymlink(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "name"), []byte("koding"), 0o644))
+	require.NoError(t, os.Symlink("missing", filepath.Join(dir, "port")))
+
+	s := &Server{}
+	require.NoError(t, (&DirectoryLoader{Path: dir}).Load(s))
+	require.Equal(t, "koding", s.Name)
+	require.Zero(t, s.Port)
+}
+
+func TestDirectoryLoaderWatchPlain(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "name"), []byte("koding"), 0o644))
+
+	changes := watchDirectory(t, &DirectoryLoader{Path: dir, Interval: 5 * time.Millisecond})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "name"), []byte("gopher"), 0o644))
+
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("no change notified")
+	}
+}
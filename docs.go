@@ -0,0 +1,204 @@
+package multiconfig
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DocGenerator writes the reference documentation of every setting of a
+// config struct: its field path, flag, environment variable, file key, type,
+// default value, whether it's required and its description. The names are
+// generated by the same logic as the loaders, so the documentation doesn't
+// drift from the code. Fields tagged with `hidden:"true"` are omitted.
+type DocGenerator struct {
+	// Flags and Env must be configured like the loaders used to load the
+	// config struct. If nil, the default loaders are used.
+	Flags *FlagLoader
+	Env   *EnvironmentLoader
+
+	// Name is the name of the program, used as the title of the man page.
+	// The default is the base name of os.Args[0].
+	Name string
+
+	// Section is the section of the man page. The default is "1".
+	Section string
+
+	// Description is a short description of the program.
+	Description string
+}
+
+// settings returns the documented settings of s, hidden fields are omitted.
+func (d *DocGenerator) settings(s any) ([]*setting, error) {
+	f, e := d.Flags, d.Env
+	if f == nil {
+		f = &FlagLoader{}
+	}
+
+	if e == nil {
+		e = &EnvironmentLoader{}
+	}
+
+	list, err := settings(s, f, e)
+	if err != nil {
+		return nil, err
+	}
+
+	documented := list[:0]
+	for _, st := range list {
+		if !st.Hidden {
+			documented = append(documented, st)
+		}
+	}
+
+	return documented, nil
+}
+
+func (d *DocGenerator) name() string {
+	if d.Name != "" {
+		return d.Name
+	}
+
+	return filepath.Base(os.Args[0])
+}
+
+// Markdown writes the documentation of the config struct s to w as a
+// Markdown table.
+func (d *DocGenerator) Markdown(s any, w io.Writer) error {
+	list, err := d.settings(s)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{
+		"| Field | Flag | Environment variable | File key | Type | Default | Required | Description |",
+		"| --- | --- | --- | --- | --- | --- | --- | --- |",
+	}
+
+	for _, st := range list {
+		required := ""
+		if st.Required {
+			required = "yes"
+		}
+
+		cells := []string{
+			markdownCode(st.Name()),
+			markdownCode(flagArg(st.Flag)),
+			markdownCode(st.Env),
+			markdownCode(st.Key()),
+			markdownCode(st.Type),
+			markdownCode(st.Default),
+			required,
+			markdownEscape(st.Usage),
+		}
+
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+	}
+
+	_, err = io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// Man writes the documentation of the config struct s to w as a roff man
+// page.
+func (d *DocGenerator) Man(s any, w io.Writer) error {
+	list, err := d.settings(s)
+	if err != nil {
+		return err
+	}
+
+	section := d.Section
+	if section == "" {
+		section = "1"
+	}
+
+	name := d.name()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s %s\n", roffEscape(strings.ToUpper(name)), roffEscape(section))
+	b.WriteString(".SH NAME\n")
+	if d.Description != "" {
+		fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(name), roffEscape(d.Description))
+	} else {
+		fmt.Fprintf(&b, "%s\n", roffEscape(name))
+	}
+
+	b.WriteString(".SH OPTIONS\n")
+	for _, st := range list {
+		b.WriteString(".TP\n")
+		if st.Flag != "" {
+			fmt.Fprintf(&b, "\\fB%s\\fR \\fI%s\\fR\n", roffEscape(flagArg(st.Flag)), roffEscape(st.Type))
+		} else {
+			fmt.Fprintf(&b, "\\fB%s\\fR \\fI%s\\fR\n", roffEscape(st.Name()), roffEscape(st.Type))
+		}
+
+		if st.Usage != "" {
+			b.WriteString(roffText(st.Usage) + "\n")
+		}
+
+		details := [][2]string{
+			{"Field", st.Name()},
+			{"Environment variable", st.Env},
+			{"File key", st.Key()},
+			{"Default", st.Default},
+		}
+
+		for _, detail := range details {
+			if detail[1] == "" {
+				continue
+			}
+
+			fmt.Fprintf(&b, ".br\n%s: \\fB%s\\fR\n", detail[0], roffEscape(detail[1]))
+		}
+
+		if st.Required {
+			b.WriteString(".br\nRequired.\n")
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// flagArg returns the flag name as it's passed on the command line.
+func flagArg(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	return "-" + name
+}
+
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "`" + markdownEscape(s) + "`"
+}
+
+// roffEscape escapes s to be used inline in a roff document.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	return strings.ReplaceAll(s, "-", `\-`)
+}
+
+// roffText escapes s to be used as text lines in a roff document, so lines
+// starting with a control character are not interpreted.
+func roffText(s string) string {
+	lines := strings.Split(roffEscape(s), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
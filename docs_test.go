@@ -0,0 +1,60 @@
+package multiconfig
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type DocServer struct {
+	Name     string `required:"true" flagUsage:"Name of the server"`
+	Port     int    `default:"6060"`
+	Password Secret `default:"changeme"`
+	Postgres struct {
+		DBName  string        `default:"configdb" toml:"db_name"`
+		Timeout time.Duration `flagUsage:"Connection | query timeout"`
+	}
+	Region string `toml:"-" json:"region"`
+}
+
+func TestDocMarkdown(t *testing.T) {
+	d := &DocGenerator{
+		Flags: &FlagLoader{CamelCase: true},
+		Env:   &EnvironmentLoader{Prefix: "app", CamelCase: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, d.Markdown(&DocServer{}, &buf))
+
+	expected := "| Field | Flag | Environment variable | File key | Type | Default | Required | Description |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+		"| `Name` | `-name` | `APP_NAME` | `Name` | `string` |  | yes | Name of the server |\n" +
+		"| `Port` | `-port` | `APP_PORT` | `Port` | `int` | `6060` |  | Change value of Port. |\n" +
+		"| `Password` | `-password` | `APP_PASSWORD` | `Password` | `string` | `******` |  | Change value of Password. |\n" +
+		"| `Postgres.DBName` | `-postgres-db-name` | `APP_POSTGRES_DB_NAME` | `Postgres.db_name (toml), Postgres.DBName (json)` | `string` | `configdb` |  | Change value of DB-Name. |\n" +
+		"| `Postgres.Timeout` | `-postgres-timeout` | `APP_POSTGRES_TIMEOUT` | `Postgres.Timeout` | `duration` |  |  | Connection \\| query timeout |\n" +
+		"| `Region` | `-region` | `APP_REGION` | `region (json)` | `string` |  |  | Change value of Region. |\n"
+	require.Equal(t, expected, buf.String())
+}
+
+func TestDocMan(t *testing.T) {
+	d := &DocGenerator{Name: "app", Description: "an example server"}
+
+	var buf bytes.Buffer
+	require.NoError(t, d.Man(&DocServer{}, &buf))
+
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, ".TH APP 1\n.SH NAME\napp \\- an example server\n.SH OPTIONS\n"), out)
+	require.Contains(t, out, ".TP\n\\fB\\-postgres\\-dbname\\fR \\fIstring\\fR\nChange value of DBName.\n"+
+		".br\nField: \\fBPostgres.DBName\\fR\n"+
+		".br\nEnvironment variable: \\fBDOCSERVER_POSTGRES_DBNAME\\fR\n"+
+		".br\nFile key: \\fBPostgres.db_name (toml), Postgres.DBName (json)\\fR\n"+
+		".br\nDefault: \\fBconfigdb\\fR\n")
+	require.Contains(t, out, ".br\nRequired.\n")
+	require.NotContains(t, out, "changeme")
+
+	require.Error(t, d.Man(DocServer{}, &buf))
+}
@@ -0,0 +1,538 @@
+package multiconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/structs"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Format is a configuration format.
+type Format string
+
+const (
+	// FormatTOML is the format read by TOMLLoader
+	FormatTOML Format = "toml"
+
+	// FormatJSON is the format read by JSONLoader
+	FormatJSON Format = "json"
+
+	// FormatYAML is the format read by YAMLLoader
+	FormatYAML Format = "yaml"
+
+	// FormatEnv is a list of NAME='value' lines, as read by
+	// EnvironmentLoader once sourced by a shell.
+	FormatEnv Format = "env"
+)
+
+// Dumper serializes a loaded configuration, i.e: to print the effective
+// configuration for debugging purposes. Keys are named the same way the
+// loaders name them and secrets are redacted.
+type Dumper struct {
+	// EnvPrefix and CamelCase are used to name the variables of FormatEnv,
+	// see EnvironmentLoader.
+	EnvPrefix string
+	CamelCase bool
+
+	// Provenance, if set, adds a comment with the source of each value.
+	// Comments are not supported by FormatJSON.
+	Provenance Provenance
+
+	// sample adds the documentation of the fields as comments, see Sample.
+	sample bool
+}
+
+// Dump writes the configuration defined by struct s to w in the given format
+// with the default Dumper settings.
+func Dump(s any, format Format, w io.Writer) error {
+	return (&Dumper{}).Dump(s, format, w)
+}
+
+// Dump writes the configuration defined by struct s to w in the given format.
+func (d *Dumper) Dump(s any, format Format, w io.Writer) error {
+	v := reflect.ValueOf(s)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("multiconfig: cannot dump %T: not a struct", s)
+	}
+
+	fields := configFields(v, nil)
+
+	switch format {
+	case FormatTOML:
+		return writeTOML(w, d.table(fields, format))
+	case FormatJSON:
+		return writeJSON(w, d.table(fields, format))
+	case FormatYAML:
+		return writeYAML(w, d.table(fields, format))
+	case FormatEnv:
+		e := &EnvironmentLoader{Prefix: d.EnvPrefix, CamelCase: d.CamelCase}
+		return writeEnv(w, d.envEntries(e, e.getPrefix(structs.New(s)), fields))
+	default:
+		return fmt.Errorf("multiconfig: unsupported format %q", format)
+	}
+}
+
+// dumpTable is an ordered list of key/value pairs: a nested struct or a map.
+type dumpTable struct {
+	entries []*dumpEntry
+}
+
+type dumpEntry struct {
+	key      string
+	comments []string
+
+	// value is either a scalar, a []any or a *dumpTable
+	value any
+}
+
+func (d *Dumper) table(fields []*configField, format Format) *dumpTable {
+	t := &dumpTable{}
+	for _, field := range fields {
+		key, ok := fieldKey(field.Field, format)
+		if !ok {
+			continue
+		}
+
+		entry := &dumpEntry{key: key, comments: d.comments(field)}
+		if field.IsNested() {
+			entry.value = d.table(field.Fields, format)
+		} else {
+			val, ok := dumpValue(field.Value, format, isSecret(field.Field))
+			if !ok {
+				continue
+			}
+
+			entry.value = val
+		}
+
+		t.entries = append(t.entries, entry)
+	}
+
+	return t
+}
+
+func (d *Dumper) comments(field *configField) []string {
+	comments := []string{}
+	if d.sample {
+		comments = append(comments, sampleComments(field)...)
+	}
+
+	if source, ok := d.Provenance[field.Name()]; ok {
+		comments = append(comments, "from "+source)
+	}
+
+	return comments
+}
+
+// fieldKey returns the key used by the file loaders of the given format for
+// the field. It returns false if the field is ignored.
+func fieldKey(sf reflect.StructField, format Format) (string, bool) {
+	name, _, _ := strings.Cut(sf.Tag.Get(string(format)), ",")
+	if name == "-" {
+		return "", false
+	}
+
+	if name != "" {
+		return name, true
+	}
+
+	// yaml.v3 lowercases field names by default
+	if format == FormatYAML {
+		return strings.ToLower(sf.Name), true
+	}
+
+	return sf.Name, true
+}
+
+// dumpValue converts v to a value which can be written in the given format.
+// It returns false if v has no value (i.e: a nil pointer).
+func dumpValue(v reflect.Value, format Format, secret bool) (any, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	if secret && !v.IsZero() {
+		return redacted, true
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+
+		return dumpValue(v.Elem(), format, secret)
+	}
+
+	if d, ok := v.Interface().(time.Duration); ok {
+		// JSONLoader only reads durations as nanoseconds
+		if format == FormatJSON {
+			return int64(d), true
+		}
+
+		return d.String(), true
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(interface{ MarshalText() ([]byte, error) }).MarshalText()
+		if err == nil {
+			return string(text), true
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return v.String(), true
+	case reflect.Slice, reflect.Array:
+		list := []any{}
+		for i := 0; i < v.Len(); i++ {
+			if val, ok := dumpValue(v.Index(i), format, false); ok {
+				list = append(list, val)
+			}
+		}
+
+		return list, true
+	case reflect.Map:
+		t := &dumpTable{}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+
+		for _, key := range keys {
+			if val, ok := dumpValue(v.MapIndex(key), format, false); ok {
+				t.entries = append(t.entries, &dumpEntry{key: fmt.Sprint(key.Interface()), value: val})
+			}
+		}
+
+		return t, true
+	case reflect.Struct:
+		return (&Dumper{}).table(configFields(v, nil), format), true
+	default:
+		return fmt.Sprint(v.Interface()), true
+	}
+}
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+
+	return tomlString(key)
+}
+
+func tomlString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return strconv.Quote(s)
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func tomlValue(val any) string {
+	switch val := val.(type) {
+	case string:
+		return tomlString(val)
+	case float64:
+		switch {
+		case math.IsNaN(val):
+			return "nan"
+		case math.IsInf(val, 1):
+			return "inf"
+		case math.IsInf(val, -1):
+			return "-inf"
+		}
+
+		f := strconv.FormatFloat(val, 'g', -1, 64)
+		if !strings.ContainsAny(f, ".e") {
+			f += ".0"
+		}
+
+		return f
+	case []any:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, tomlValue(item))
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	case *dumpTable:
+		items := make([]string, 0, len(val.entries))
+		for _, entry := range val.entries {
+			items = append(items, tomlKey(entry.key)+" = "+tomlValue(entry.value))
+		}
+
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func writeComments(w io.Writer, comments []string) error {
+	for _, comment := range comments {
+		if _, err := fmt.Fprintf(w, "# %s\n", comment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTOML(w io.Writer, t *dumpTable) error {
+	_, err := writeTOMLTable(w, t, nil, false)
+	return err
+}
+
+// writeTOMLTable writes the key/value pairs of t first, then its tables as
+// sections, as TOML requires. It returns whether anything was written.
+func writeTOMLTable(w io.Writer, t *dumpTable, path []string, written bool) (bool, error) {
+	for _, entry := range t.entries {
+		if _, ok := entry.value.(*dumpTable); ok {
+			continue
+		}
+
+		if err := writeComments(w, entry.comments); err != nil {
+			return written, err
+		}
+
+		if _, err := fmt.Fprintf(w, "%s = %s\n", tomlKey(entry.key), tomlValue(entry.value)); err != nil {
+			return written, err
+		}
+
+		written = true
+	}
+
+	for _, entry := range t.entries {
+		table, ok := entry.value.(*dumpTable)
+		if !ok {
+			continue
+		}
+
+		if written {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return written, err
+			}
+		}
+
+		if err := writeComments(w, entry.comments); err != nil {
+			return written, err
+		}
+
+		section := append(path[:len(path):len(path)], tomlKey(entry.key))
+		if _, err := fmt.Fprintf(w, "[%s]\n", strings.Join(section, ".")); err != nil {
+			return written, err
+		}
+
+		var err error
+		if written, err = writeTOMLTable(w, table, section, true); err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// MarshalJSON writes the table as a JSON object, keeping the order of the
+// entries.
+func (t *dumpTable) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, entry := range t.entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(entry.key)
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func writeJSON(w io.Writer, t *dumpTable) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(t)
+}
+
+// yamlNode converts a dump value to a yaml.Node, keeping the order and the
+// comments of the tables.
+func yamlNode(val any) (*yaml.Node, error) {
+	switch val := val.(type) {
+	case *dumpTable:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, entry := range val.entries {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Value: entry.key}
+			if len(entry.comments) > 0 {
+				key.HeadComment = "# " + strings.Join(entry.comments, "\n# ")
+			}
+
+			value, err := yamlNode(entry.value)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, key, value)
+		}
+
+		return node, nil
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range val {
+			value, err := yamlNode(item)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, value)
+		}
+
+		return node, nil
+	default:
+		node := &yaml.Node{}
+		if err := node.Encode(val); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	}
+}
+
+func writeYAML(w io.Writer, t *dumpTable) error {
+	node, err := yamlNode(t)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+type envEntry struct {
+	name     string
+	value    string
+	comments []string
+}
+
+func (d *Dumper) envEntries(e *EnvironmentLoader, prefix string, fields []*configField) []*envEntry {
+	entries := []*envEntry{}
+	for _, field := range fields {
+		name, opts, _ := strings.Cut(field.Field.Tag.Get("structs"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Field.Name
+		}
+
+		fieldName := e.generateFieldName(prefix, name)
+
+		if field.IsNested() {
+			// flattened structs are named like the fields of their parent
+			if strings.Contains(opts, "flatten") {
+				fieldName = prefix
+			}
+
+			entries = append(entries, d.envEntries(e, fieldName, field.Fields)...)
+			continue
+		}
+
+		val, ok := dumpValue(field.Value, FormatEnv, isSecret(field.Field))
+		if !ok {
+			continue
+		}
+
+		entries = append(entries, &envEntry{
+			name:     fieldName,
+			value:    envValue(val),
+			comments: d.comments(field),
+		})
+	}
+
+	return entries
+}
+
+// envValue formats val the way fieldSet parses it.
+func envValue(val any) string {
+	switch val := val.(type) {
+	case []any:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, envValue(item))
+		}
+
+		return strings.Join(items, ",")
+	case *dumpTable:
+		items := make([]string, 0, len(val.entries))
+		for _, entry := range val.entries {
+			items = append(items, entry.key+"="+envValue(entry.value))
+		}
+
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func writeEnv(w io.Writer, entries []*envEntry) error {
+	for _, entry := range entries {
+		if err := writeComments(w, entry.comments); err != nil {
+			return err
+		}
+
+		// single quotes keep the value as is once sourced by a shell
+		value := "'" + strings.ReplaceAll(entry.value, "'", `'\''`) + "'"
+		if _, err := fmt.Fprintf(w, "%s=%s\n", entry.name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package multiconfig
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpRoundTrip(t *testing.T) {
+	loaders := map[Format]func(*bytes.Buffer) Loader{
+		FormatTOML: func(b *bytes.Buffer) Loader { return &TOMLLoader{Reader: b} },
+		FormatJSON: func(b *bytes.Buffer) Loader { return &JSONLoader{Reader: b} },
+		FormatYAML: func(b *bytes.Buffer) Loader { return &YAMLLoader{Reader: b} },
+	}
+
+	for format, loader := range loaders {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Dump(getDefaultServer(), format, &buf))
+
+			s := &Server{}
+			require.NoError(t, loader(&buf).Load(s), buf.String())
+			testStruct(t, s, getDefaultServer())
+		})
+	}
+}
+
+func TestDumpEnv(t *testing.T) {
+	var buf bytes.Buffer
+	d := &Dumper{EnvPrefix: "App"}
+	require.NoError(t, d.Dump(getDefaultServer(), FormatEnv, &buf))
+
+	require.Contains(t, buf.String(), "APP_POSTGRES_HOSTS='192.168.2.1,192.168.2.2,192.168.2.3'\n")
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		name, value, ok := strings.Cut(line, "=")
+		require.True(t, ok, line)
+		t.Setenv(name, strings.Trim(value, "'"))
+	}
+
+	s := &Server{}
+	require.NoError(t, (&EnvironmentLoader{Prefix: "App"}).Load(s))
+	testStruct(t, s, getDefaultServer())
+}
+
+func TestDumpSecrets(t *testing.T) {
+	c := &SecretServer{Name: "koding", Password: "s3cr3t", Token: "t0k3n"}
+
+	for _, format := range []Format{FormatTOML, FormatJSON, FormatYAML, FormatEnv} {
+		var buf bytes.Buffer
+		require.NoError(t, Dump(c, format, &buf))
+		require.Contains(t, buf.String(), "koding")
+		require.Contains(t, buf.String(), "******")
+		require.NotContains(t, buf.String(), "s3cr3t")
+		require.NotContains(t, buf.String(), "t0k3n")
+	}
+}
+
+func TestDumpProvenance(t *testing.T) {
+	t.Setenv("SERVER_NAME", "gopher")
+
+	m := NewWithPath(testTOML)
+	s := &Server{}
+	require.NoError(t, m.Load(s))
+
+	var buf bytes.Buffer
+	d := &Dumper{Provenance: m.Provenance}
+	require.NoError(t, d.Dump(s, FormatTOML, &buf))
+
+	out := buf.String()
+	require.Contains(t, out, "# from environment\nName = \"gopher\"\n")
+	require.Contains(t, out, "# from default tag\nPort = 6060\n")
+	require.Contains(t, out, "# from toml file testdata/config.toml\nEnabled = true\n")
+	require.Contains(t, out, "\n[Postgres]\n")
+
+	buf.Reset()
+	require.NoError(t, d.Dump(s, FormatYAML, &buf))
+	require.Contains(t, buf.String(), "# from environment\nname: gopher\n")
+
+	require.Error(t, Dump(s, Format("ini"), &buf))
+}
@@ -2,7 +2,9 @@ package multiconfig
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -23,6 +25,28 @@ type EnvironmentLoader struct {
 	// "STRUCTNAME_ACCESSKEY". If CamelCase is enabled, the environment name
 	// will be generated in the form of "STRUCTNAME_ACCESS_KEY"
 	CamelCase bool
+
+	// ResolveFiles enables the "_FILE" convention used for Docker and
+	// Kubernetes secrets. If set, an environment variable named
+	// {NAME}_FILE is read as the path of a file and its content, with
+	// leading and trailing whitespace removed, is used as the value of
+	// {NAME}. Setting both {NAME} and {NAME}_FILE is an error.
+	ResolveFiles bool
+
+	// AllowEmpty makes variables which are set to an empty string set the
+	// field, instead of being ignored like unset variables. String fields
+	// are set to "", pointers to strings to a pointer to "", and other
+	// fields are reset to their zero value (nil for pointers).
+	AllowEmpty bool
+
+	// AliasPolicy defines what happens when a field is set by both its
+	// environment variable and one of the variables generated from its
+	// "aliases" tag.
+	AliasPolicy AliasPolicy
+
+	// Logger logs the decisions of the loader at the debug level. Nothing
+	// is logged if it's nil.
+	Logger *slog.Logger
 }
 
 func (e *EnvironmentLoader) getPrefix(s *structs.Struct) string {
@@ -35,51 +59,166 @@ func (e *EnvironmentLoader) getPrefix(s *structs.Struct) string {
 
 // Load loads the source into the config defined by struct s
 func (e *EnvironmentLoader) Load(s any) error {
+	_, err := e.loadFileVars(s)
+	return err
+}
+
+// loadFileVars loads s like Load and returns the {NAME}_FILE variables the
+// fields were read from, keyed by the path of the fields.
+func (e *EnvironmentLoader) loadFileVars(s any) (map[string]string, error) {
+	if err := checkStructPointer(s); err != nil {
+		return nil, err
+	}
+
 	strct := structs.New(s)
 	strctMap := strct.Map()
 	prefix := e.getPrefix(strct)
 
+	fileVars := map[string]string{}
 	for key, val := range strctMap {
 		field := strct.Field(key)
 
-		if err := e.processField(prefix, field, key, val); err != nil {
-			return err
+		if err := e.processField([]string{prefix}, nil, field, key, val, fileVars); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return fileVars, nil
 }
 
-// processField gets leading name for the env variable and combines the current
-// field's name and generates environment variable names recursively
-func (e *EnvironmentLoader) processField(prefix string, field *structs.Field, name string, strctMap any) error {
-	fieldName := e.generateFieldName(prefix, name)
+// processField gets leading names for the env variable and combines the
+// current field's name and its aliases, and generates environment variable
+// names recursively. The first prefix is the one of the field's name, the
+// others come from the aliases of its parents. path holds the names of the
+// parents of the field. The {NAME}_FILE variables the fields are read from
+// are recorded in fileVars.
+func (e *EnvironmentLoader) processField(prefixes []string, path []string, field *structs.Field, name string, strctMap any, fileVars map[string]string) error {
+	path = append(path[:len(path):len(path)], field.Name())
+
+	names := []string{}
+	for _, prefix := range prefixes {
+		for _, n := range append([]string{name}, fieldAliases(field.Tag("aliases"))...) {
+			names = append(names, e.generateFieldName(prefix, n))
+		}
+	}
 
 	switch smap := strctMap.(type) {
 	case map[string]any:
 		for key, val := range smap {
 			field := field.Field(key)
 
-			if err := e.processField(fieldName, field, key, val); err != nil {
+			if err := e.processField(names, path, field, key, val, fileVars); err != nil {
 				return err
 			}
 		}
 	default:
-		v := os.Getenv(fieldName)
-		if v == "" {
+		values := make([]string, len(names))
+		set := make([]bool, len(names))
+		fromFile := make([]bool, len(names))
+		for i, fieldName := range names {
+			v, ok := os.LookupEnv(fieldName)
+			if e.ResolveFiles {
+				fv, err := e.readFileVar(fieldName, v)
+				if err != nil {
+					return &LoadError{
+						Loader: "environment",
+						Source: fieldName + "_FILE",
+						Field:  strings.Join(path, "."),
+						Err:    err,
+					}
+				}
+
+				if fv != "" {
+					v, ok, fromFile[i] = fv, true, true
+				}
+			}
+
+			values[i], set[i] = v, v != "" || (e.AllowEmpty && ok)
+		}
+
+		chosen, err := e.AliasPolicy.choose(names, set)
+		if err != nil {
+			return &LoadError{Loader: "environment", Field: strings.Join(path, "."), Err: err}
+		}
+
+		if chosen < 0 {
 			return nil
 		}
 
-		if err := fieldSet(field, v); err != nil {
-			return err
+		// the content of a file is redacted as it's usually a secret
+		env, secret := names[chosen], isSecretField(field)
+		if fromFile[chosen] {
+			env, secret = env+"_FILE", true
+			fileVars[strings.Join(path, ".")] = env
+		}
+
+		if e.Logger != nil {
+			e.Logger.Debug("environment variable found",
+				"env", env,
+				"field", strings.Join(path, "."),
+				"value", logValue(values[chosen], secret),
+			)
+		}
+
+		if values[chosen] == "" && !isStringType(reflect.TypeOf(field.Value())) {
+			return field.Zero()
+		}
+
+		if err := fieldSet(field, values[chosen]); err != nil {
+			return &LoadError{
+				Loader: "environment",
+				Source: env,
+				Field:  strings.Join(path, "."),
+				Err:    err,
+			}
 		}
 	}
 
 	return nil
 }
 
+// readFileVar returns the trimmed content of the file pointed by the
+// {NAME}_FILE environment variable, or an empty string if it's not set.
+func (e *EnvironmentLoader) readFileVar(fieldName, value string) (string, error) {
+	fileVar := fieldName + "_FILE"
+	path := os.Getenv(fileVar)
+	if path == "" {
+		return "", nil
+	}
+
+	if value != "" {
+		return "", fmt.Errorf("both %s and %s are set", fieldName, fileVar)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", fileVar, err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
 // PrintEnvs prints the generated environment variables to the std out.
-func (e *EnvironmentLoader) PrintEnvs(s any) {
+// Fields tagged with `hidden:"true"` are not printed. An ErrNotStructPointer
+// error is returned if s is not a struct or a pointer to a struct.
+func (e *EnvironmentLoader) PrintEnvs(s any) error {
+	if !structs.IsStruct(s) {
+		return &notStructPointerError{msg: fmt.Sprintf("multiconfig: %T is not a struct", s)}
+	}
+
+	e.visitEnvs(s, func(_ []string, fieldName string, hidden bool) {
+		if !hidden {
+			fmt.Println("  ", fieldName)
+		}
+	})
+
+	return nil
+}
+
+// visitEnvs calls fn with the path and the generated environment variable of
+// each field of the config struct s, sorted by name. hidden is true if the
+// field or one of its parents is tagged with `hidden:"true"`.
+func (e *EnvironmentLoader) visitEnvs(s any, fn func(path []string, fieldName string, hidden bool)) {
 	strct := structs.New(s)
 	strctMap := strct.Map()
 	prefix := e.getPrefix(strct)
@@ -92,13 +231,15 @@ func (e *EnvironmentLoader) PrintEnvs(s any) {
 
 	for _, key := range keys {
 		field := strct.Field(key)
-		e.printField(prefix, field, key, strctMap[key])
+		e.visitField(prefix, nil, false, field, key, strctMap[key], fn)
 	}
 }
 
-// printField prints the field of the config struct for the flag.Usage
-func (e *EnvironmentLoader) printField(prefix string, field *structs.Field, name string, strctMap any) {
+// visitField visits the field of the config struct, see visitEnvs
+func (e *EnvironmentLoader) visitField(prefix string, path []string, hidden bool, field *structs.Field, name string, strctMap any, fn func([]string, string, bool)) {
 	fieldName := e.generateFieldName(prefix, name)
+	path = append(path[:len(path):len(path)], field.Name())
+	hidden = hidden || field.Tag("hidden") == "true"
 
 	switch smap := strctMap.(type) {
 	case map[string]any:
@@ -109,10 +250,10 @@ func (e *EnvironmentLoader) printField(prefix string, field *structs.Field, name
 		sort.Strings(keys)
 		for _, key := range keys {
 			field := field.Field(key)
-			e.printField(fieldName, field, key, smap[key])
+			e.visitField(fieldName, path, hidden, field, key, smap[key], fn)
 		}
 	default:
-		fmt.Println("  ", fieldName)
+		fn(path, fieldName, hidden)
 	}
 }
 
@@ -126,3 +267,12 @@ func (e *EnvironmentLoader) generateFieldName(prefix string, name string) string
 
 	return strings.ToUpper(prefix) + "_" + fieldName
 }
+
+// isStringType reports whether t is a string type or a pointer to one.
+func isStringType(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.String
+}
@@ -1,6 +1,8 @@
 package multiconfig
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -171,3 +173,66 @@ func TestMapEnvSupport(t *testing.T) {
 	require.EqualValues(t, map[string]int{"key1": 1234, "key2": 456}, e.MapStringInt)
 	require.EqualValues(t, map[string]string{"key1": "val1", "key2": "val2"}, e.MapStringString)
 }
+
+func TestENVResolveFiles(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db_password")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cr3t\n"), 0o600))
+
+	type Config struct {
+		Name     string
+		Password string
+	}
+
+	t.Setenv("CONFIG_NAME", "koding")
+	t.Setenv("CONFIG_PASSWORD_FILE", secret)
+
+	// disabled by default
+	c := &Config{}
+	require.NoError(t, (&EnvironmentLoader{}).Load(c))
+	require.Equal(t, "koding", c.Name)
+	require.Empty(t, c.Password)
+
+	c = &Config{}
+	m := &EnvironmentLoader{ResolveFiles: true}
+	require.NoError(t, m.Load(c))
+	require.Equal(t, "koding", c.Name)
+	require.Equal(t, "s3cr3t", c.Password)
+
+	t.Setenv("CONFIG_PASSWORD", "other")
+	err := m.Load(&Config{})
+	require.EqualError(t, err, "multiconfig: environment CONFIG_PASSWORD_FILE: field 'Password': both CONFIG_PASSWORD and CONFIG_PASSWORD_FILE are set")
+
+	t.Setenv("CONFIG_PASSWORD", "")
+	t.Setenv("CONFIG_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	require.Error(t, m.Load(&Config{}))
+}
+
+func TestENVAllowEmpty(t *testing.T) {
+	type Server struct {
+		Name    string `default:"koding"`
+		Port    int    `default:"6060"`
+		Label   *string
+		Enabled *bool `default:"true"`
+	}
+
+	t.Setenv("SERVER_NAME", "")
+	t.Setenv("SERVER_PORT", "")
+	t.Setenv("SERVER_LABEL", "")
+	t.Setenv("SERVER_ENABLED", "")
+
+	s := &Server{}
+	require.NoError(t, MultiLoader(&TagLoader{}, &EnvironmentLoader{}).Load(s))
+	require.Equal(t, "koding", s.Name)
+	require.Equal(t, 6060, s.Port)
+	require.Nil(t, s.Label)
+	require.True(t, *s.Enabled)
+
+	s = &Server{}
+	require.NoError(t, MultiLoader(&TagLoader{}, &EnvironmentLoader{AllowEmpty: true}).Load(s))
+	require.Equal(t, "", s.Name)
+	require.Equal(t, 0, s.Port)
+	require.NotNil(t, s.Label)
+	require.Equal(t, "", *s.Label)
+	require.Nil(t, s.Enabled)
+}
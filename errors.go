@@ -0,0 +1,186 @@
+package multiconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadError is returned by the built-in loaders when a value cannot be
+// loaded. It tells which loader failed, where the value comes from and which
+// field it's loaded into. MultiLoader wraps the errors of other loaders in a
+// LoadError too.
+type LoadError struct {
+	// Loader is the kind of the loader, i.e: "environment", "flags",
+	// "default tag" or "toml file".
+	Loader string
+
+	// Source is the key of the value in the source, i.e: the name of the
+	// environment variable, the flag or the path of the file. It's empty if
+	// it's unknown.
+	Source string
+
+	// Line is the line of the error in the file, or 0 if it's unknown.
+	Line int
+
+	// Field is the dotted path of the field, i.e: "Postgres.Port". It's
+	// empty if it's unknown. The errors of the file loaders hold the key of
+	// the file instead, i.e: "postgres.port", when it doesn't match a field.
+	Field string
+
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	msg := "multiconfig: " + e.Loader
+	if e.Source != "" {
+		msg += " " + e.Source
+	}
+
+	if e.Line > 0 {
+		msg += fmt.Sprintf(":%d", e.Line)
+	}
+
+	if e.Field != "" {
+		msg += fmt.Sprintf(": field '%s'", e.Field)
+	}
+
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// wrapLoadError wraps the error of the loader l in a LoadError, unless it's
+// already one or it's an ErrNotStructPointer error.
+func wrapLoadError(l Loader, err error) error {
+	var loadErr *LoadError
+	if err == nil || errors.As(err, &loadErr) || errors.Is(err, ErrNotStructPointer) {
+		return err
+	}
+
+	return &LoadError{Loader: describeLoader(l), Err: err}
+}
+
+// fileLoadError wraps the error of a file loader in a LoadError. data is the
+// content of the file, used to find the line of the error.
+func fileLoadError(format Format, path string, data []byte, err error) error {
+	loader := string(format) + " file"
+	if path == "" {
+		loader = string(format) + " reader"
+	}
+
+	loadErr := &LoadError{
+		Loader: loader,
+		Source: path,
+		Line:   fileErrorLine(data, err),
+		Err:    err,
+	}
+
+	var (
+		typeErr      *json.UnmarshalTypeError
+		tomlParseErr toml.ParseError
+	)
+
+	switch {
+	case errors.As(err, &typeErr):
+		loadErr.Field = typeErr.Field
+	case errors.As(err, &tomlParseErr):
+		loadErr.Field = tomlParseErr.LastKey
+	default:
+		// the type errors of the toml decoder only mention the key in
+		// their message
+		if m := tomlLastKey.FindStringSubmatch(err.Error()); m != nil {
+			loadErr.Field = m[1]
+		}
+	}
+
+	return loadErr
+}
+
+// decodeError wraps the error of the decoding of the section of a config file
+// into the struct s in a LoadError, see fileLoadError. The key of the error
+// is replaced with the path of the field it's decoded into.
+func decodeError(format Format, path string, data []byte, err error, s any, section fileSection) error {
+	wrapped := fileLoadError(format, path, data, err)
+
+	var loadErr *LoadError
+	if !errors.As(wrapped, &loadErr) || loadErr.Field == "" {
+		return wrapped
+	}
+
+	key := strings.Split(loadErr.Field, ".")
+	if len(key) <= len(section.path) || !slices.Equal(key[:len(section.path)], section.path) {
+		return wrapped
+	}
+
+	if field, ok := fieldPath(reflect.TypeOf(s), key[len(section.path):], format); ok {
+		loadErr.Field = field
+	}
+
+	return wrapped
+}
+
+// fieldPath returns the dotted path of the field of the type t the key of a
+// file of the given format is decoded into, or false if the key doesn't match
+// a field. The keys of maps are kept as is.
+func fieldPath(t reflect.Type, key []string, format Format) (string, bool) {
+	var path []string
+	for i, k := range key {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Map:
+			return strings.Join(append(path, key[i:]...), "."), len(path) > 0
+		case reflect.Struct:
+		default:
+			return "", false
+		}
+
+		sf, _, ok := aliasField(t, k, format)
+		if !ok {
+			return "", false
+		}
+
+		path, t = append(path, sf.Name), sf.Type
+	}
+
+	return strings.Join(path, "."), len(path) > 0
+}
+
+var tomlLastKey = regexp.MustCompile(`^toml: line \d+ \(last key "([^"]+)"\)`)
+
+// fileErrorLine returns the line of the error of a decoder of the file data,
+// or 0 if it's unknown.
+func fileErrorLine(data []byte, err error) int {
+	var (
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		tomlParseErr toml.ParseError
+		yamlTypeErr  *yaml.TypeError
+	)
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return lineAt(data, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		return lineAt(data, typeErr.Offset)
+	case errors.As(err, &tomlParseErr):
+		return tomlParseErr.Position.Line
+	case errors.As(err, &yamlTypeErr) && len(yamlTypeErr.Errors) > 0:
+		// the errors are sorted by line
+		return messageLine(yamlTypeErr.Errors[0])
+	default:
+		return messageLine(err.Error())
+	}
+}
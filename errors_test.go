@@ -0,0 +1,93 @@
+package multiconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type loaderFunc func(s any) error
+
+func (f loaderFunc) Load(s any) error { return f(s) }
+
+func TestLoadError(t *testing.T) {
+	type Server struct {
+		Name     string
+		Postgres struct {
+			Port int `default:"postgres"`
+		}
+	}
+
+	requireLoadError := func(t *testing.T, err error, expected LoadError) {
+		t.Helper()
+
+		var loadErr *LoadError
+		require.ErrorAs(t, err, &loadErr)
+		require.Error(t, loadErr.Err)
+
+		expected.Err = loadErr.Err
+		require.Equal(t, &expected, loadErr)
+	}
+
+	err := (&TagLoader{}).Load(&Server{})
+	requireLoadError(t, err, LoadError{Loader: "default tag", Source: `default:"postgres"`, Field: "Postgres.Port"})
+	require.ErrorContains(t, err, `multiconfig: default tag default:"postgres": field 'Postgres.Port': cannot parse value 'postgres'`)
+
+	t.Setenv("SERVER_POSTGRES_PORT", "x")
+	err = (&EnvironmentLoader{}).Load(&Server{})
+	requireLoadError(t, err, LoadError{Loader: "environment", Source: "SERVER_POSTGRES_PORT", Field: "Postgres.Port"})
+
+	err = (&FlagLoader{Args: []string{"-postgres-port", "x"}, Output: &strings.Builder{}}).Load(&Server{})
+	requireLoadError(t, err, LoadError{Loader: "flags", Source: "-postgres-port", Field: "Postgres.Port"})
+
+	err = (&FlagLoader{Args: []string{"-unknown"}, Output: &strings.Builder{}}).Load(&Server{})
+	requireLoadError(t, err, LoadError{Loader: "flags"})
+
+	data := "Name = \"koding\"\n\n[Postgres]\nPort = \"x\"\n"
+	err = (&TOMLLoader{Reader: strings.NewReader(data)}).Load(&Server{})
+	requireLoadError(t, err, LoadError{Loader: "toml reader", Line: 4, Field: "Postgres.Port"})
+
+	data = "{\n  \"Postgres\": {\n    \"Port\": \"x\"\n  }\n}"
+	err = (&JSONLoader{Reader: strings.NewReader(data)}).Load(&Server{})
+	requireLoadError(t, err, LoadError{Loader: "json reader", Line: 3, Field: "Postgres.Port"})
+
+	// the keys of the file are mapped to the fields
+	type TaggedServer struct {
+		Postgres struct {
+			DBPort int `toml:"db_port" json:"db_port"`
+		} `toml:"pg" json:"pg"`
+		Labels map[string]int `json:"labels"`
+	}
+
+	data = "[pg]\ndb_port = \"x\"\n"
+	err = (&TOMLLoader{Reader: strings.NewReader(data)}).Load(&TaggedServer{})
+	requireLoadError(t, err, LoadError{Loader: "toml reader", Line: 2, Field: "Postgres.DBPort"})
+
+	data = `{"pg": {"db_port": 1}, "labels": {"app": "x"}}`
+	err = (&JSONLoader{Reader: strings.NewReader(data)}).Load(&TaggedServer{})
+	requireLoadError(t, err, LoadError{Loader: "json reader", Line: 1, Field: "Labels.app"})
+
+	err = (&YAMLLoader{Path: "testdata/missing.yaml"}).Load(&Server{})
+	requireLoadError(t, err, LoadError{Loader: "yaml file", Source: "testdata/missing.yaml"})
+	require.ErrorIs(t, err, ErrFileNotFound)
+	require.EqualError(t, err, "multiconfig: yaml file testdata/missing.yaml: config file not found")
+}
+
+func TestMultiLoaderLoadError(t *testing.T) {
+	errCustom := errors.New("custom")
+
+	err := MultiLoader(&TagLoader{}, loaderFunc(func(any) error { return errCustom })).Load(&Server{})
+	require.ErrorIs(t, err, errCustom)
+	require.EqualError(t, err, "multiconfig: multiconfig.loaderFunc: custom")
+
+	// errors of the built-in loaders are not wrapped twice
+	t.Setenv("SERVER_PORT", "x")
+	err = MultiLoader(&TagLoader{}, &EnvironmentLoader{}).Load(&Server{})
+	require.ErrorContains(t, err, "multiconfig: environment SERVER_PORT: field 'Port': ")
+
+	err = MultiLoader(&TagLoader{}).Load(Server{})
+	require.ErrorIs(t, err, ErrNotStructPointer)
+	require.EqualError(t, err, "multiconfig: multiconfig.Server is not a non-nil pointer to a struct")
+}
@@ -1,11 +1,19 @@
 package multiconfig
 
 import (
+	"bytes"
+	"cmp"
+	"encoding"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	yaml "gopkg.in/yaml.v3"
@@ -19,37 +27,118 @@ var (
 	ErrFileNotFound = errors.New("config file not found")
 )
 
+// UnknownKey is a key of a config file which doesn't match any field.
+type UnknownKey struct {
+	// Key is the dotted path of the key, i.e: "Postgres.Foo"
+	Key string
+
+	// Line is the line of the key in the file, or 0 if it's unknown
+	Line int
+}
+
+// UnknownKeysError is returned by the file loaders in strict mode when the
+// file has keys which don't match any field of the config struct.
+type UnknownKeysError struct {
+	Keys []UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	keys := make([]string, 0, len(e.Keys))
+	for _, key := range e.Keys {
+		keys = append(keys, fmt.Sprintf("%q", key.Key))
+	}
+
+	return "multiconfig: unknown keys " + strings.Join(keys, ", ")
+}
+
 // TOMLLoader satisifies the loader interface. It loads the configuration from
 // the given toml file or Reader.
 type TOMLLoader struct {
 	Path   string
 	Reader io.Reader
+
+	// Strict makes keys which don't match any field an error, see
+	// UnknownKeysError.
+	Strict bool
+
+	// AliasPolicy defines what happens when a field is set by both its key
+	// and one of the keys of its "aliases" tag.
+	AliasPolicy AliasPolicy
+
+	// Logger logs the decisions of the loader at the debug level. Nothing
+	// is logged if it's nil.
+	Logger *slog.Logger
+
+	section fileSection
 }
 
 // Load loads the source into the config defined by struct s
 // Defaults to using the Reader if provided, otherwise tries to read from the
 // file
 func (t *TOMLLoader) Load(s any) error {
-	var r io.Reader
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
+	data, err := readSource(t.Path, t.Reader, t.Logger)
+	if err != nil {
+		return fileLoadError(FormatTOML, t.Path, nil, err)
+	}
+
+	var before map[string]any
+	if t.Logger != nil {
+		before = snapshot(s)
+	}
+
+	if err := t.decode(data, s); err != nil {
+		return decodeError(FormatTOML, t.Path, data, err, s, t.section)
+	}
 
-	if t.Reader != nil {
-		r = t.Reader
-	} else if t.Path != "" {
-		file, err := getConfig(t.Path)
+	logChanges(t.Logger, "file value loaded", s, before, "source", describeLoader(t))
+	return nil
+}
+
+func (t *TOMLLoader) decode(data []byte, s any) error {
+	var root toml.Primitive
+	md, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&root)
+	if err != nil {
+		return err
+	}
+
+	// the tables are walked with their own metadata, so the keys they hold
+	// are not marked as decoded
+	var lazy toml.Primitive
+	lmd, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&lazy)
+	if err != nil {
+		return err
+	}
+
+	d := tomlAliases{md: lmd}
+	raw, ok := sectionValue(d, lazy, t.section.path)
+	if !ok {
+		return nil
+	}
+
+	if len(t.section.path) == 0 {
+		raw = root
+	}
+
+	if err := md.PrimitiveDecode(raw.(toml.Primitive), s); err != nil {
+		return err
+	}
+
+	if hasAliases(reflect.TypeOf(s)) {
+		err := decodeAliases(d, raw, reflect.ValueOf(s).Elem(), FormatTOML, "", t.AliasPolicy)
 		if err != nil {
 			return err
 		}
-		defer file.Close()
-		r = file
-	} else {
-		return ErrSourceNotSet
 	}
 
-	if _, err := toml.NewDecoder(r).Decode(s); err != nil {
-		return err
+	if !t.Strict {
+		return nil
 	}
 
-	return nil
+	return tomlUnknownKeys(data, md.Undecoded(), reflect.TypeOf(s), t.section)
 }
 
 // JSONLoader satisifies the loader interface. It loads the configuration from
@@ -57,27 +146,82 @@ func (t *TOMLLoader) Load(s any) error {
 type JSONLoader struct {
 	Path   string
 	Reader io.Reader
+
+	// Strict makes keys which don't match any field an error, see
+	// UnknownKeysError.
+	Strict bool
+
+	// AliasPolicy defines what happens when a field is set by both its key
+	// and one of the keys of its "aliases" tag.
+	AliasPolicy AliasPolicy
+
+	// Logger logs the decisions of the loader at the debug level. Nothing
+	// is logged if it's nil.
+	Logger *slog.Logger
+
+	section fileSection
 }
 
 // Load loads the source into the config defined by struct s.
 // Defaults to using the Reader if provided, otherwise tries to read from the
 // file
 func (j *JSONLoader) Load(s any) error {
-	var r io.Reader
-	if j.Reader != nil {
-		r = j.Reader
-	} else if j.Path != "" {
-		file, err := getConfig(j.Path)
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
+	data, err := readSource(j.Path, j.Reader, j.Logger)
+	if err != nil {
+		return fileLoadError(FormatJSON, j.Path, nil, err)
+	}
+
+	var before map[string]any
+	if j.Logger != nil {
+		before = snapshot(s)
+	}
+
+	if err := j.decode(data, s); err != nil {
+		return decodeError(FormatJSON, j.Path, data, err, s, j.section)
+	}
+
+	logChanges(j.Logger, "file value loaded", s, before, "source", describeLoader(j))
+	return nil
+}
+
+func (j *JSONLoader) decode(data []byte, s any) error {
+	raw, ok := sectionValue(jsonAliases{}, jsonValue{data: data}, j.section.path)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case len(j.section.path) > 0:
+		err := jsonAliases{}.decode(raw, reflect.ValueOf(s).Elem(), strings.Join(j.section.path, "."))
+		if err != nil {
+			return err
+		}
+	case !j.Strict:
+		if err := json.NewDecoder(bytes.NewReader(data)).Decode(s); err != nil {
+			return err
+		}
+	default:
+		if err := json.Unmarshal(data, s); err != nil {
+			return err
+		}
+	}
+
+	if hasAliases(reflect.TypeOf(s)) {
+		err := decodeAliases(jsonAliases{}, raw, reflect.ValueOf(s).Elem(), FormatJSON, "", j.AliasPolicy)
 		if err != nil {
 			return err
 		}
-		defer file.Close()
-		r = file
-	} else {
-		return ErrSourceNotSet
 	}
 
-	return json.NewDecoder(r).Decode(s)
+	if !j.Strict {
+		return nil
+	}
+
+	return jsonUnknownKeys(data, raw.(jsonValue), reflect.TypeOf(s), j.section)
 }
 
 // YAMLLoader satisifies the loader interface. It loads the configuration from
@@ -85,36 +229,222 @@ func (j *JSONLoader) Load(s any) error {
 type YAMLLoader struct {
 	Path   string
 	Reader io.Reader
+
+	// Strict makes keys which don't match any field an error. Unlike the
+	// other file loaders, the error is the *yaml.TypeError of the decoder
+	// and holds the line of each unknown key.
+	Strict bool
+
+	// AliasPolicy defines what happens when a field is set by both its key
+	// and one of the keys of its "aliases" tag.
+	AliasPolicy AliasPolicy
+
+	// Logger logs the decisions of the loader at the debug level. Nothing
+	// is logged if it's nil.
+	Logger *slog.Logger
+
+	section fileSection
 }
 
 // Load loads the source into the config defined by struct s.
 // Defaults to using the Reader if provided, otherwise tries to read from the
 // file
 func (y *YAMLLoader) Load(s any) error {
-	var r io.Reader
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
 
-	if y.Reader != nil {
-		r = y.Reader
-	} else if y.Path != "" {
-		file, err := getConfig(y.Path)
-		if err != nil {
+	data, err := readSource(y.Path, y.Reader, y.Logger)
+	if err != nil {
+		return fileLoadError(FormatYAML, y.Path, nil, err)
+	}
+
+	var before map[string]any
+	if y.Logger != nil {
+		before = snapshot(s)
+	}
+
+	if err := y.decode(data, s); err != nil {
+		return decodeError(FormatYAML, y.Path, data, err, s, y.section)
+	}
+
+	logChanges(y.Logger, "file value loaded", s, before, "source", describeLoader(y))
+	return nil
+}
+
+func (y *YAMLLoader) decode(data []byte, s any) error {
+	if hasAliases(reflect.TypeOf(s)) || len(y.section.path) > 0 || y.section.skip != "" {
+		return y.decodeNode(data, s)
+	}
+
+	if !y.Strict {
+		return yaml.Unmarshal(data, s)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// decodeNode decodes the data into s through its nodes, when s has fields
+// with aliases or when only a section of the file is loaded. The unknown
+// keys of the strict mode are reported like the decoder does, along with its
+// type errors.
+func (y *YAMLLoader) decodeNode(data []byte, s any) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind == yaml.MappingNode && y.section.skip != "" {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == y.section.skip {
+				root.Content = slices.Delete(root.Content, i, i+2)
+				break
+			}
+		}
+	}
+
+	raw, ok := sectionValue(yamlAliases{}, root, y.section.path)
+	if !ok {
+		return nil
+	}
+
+	var errs []string
+	addErr := func(err error) error {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
 			return err
 		}
-		defer file.Close()
-		r = file
-	} else {
-		return ErrSourceNotSet
+
+		errs = append(errs, typeErr.Errors...)
+		return nil
 	}
 
-	data, err := io.ReadAll(r)
-	if err != nil {
+	node := raw.(*yaml.Node)
+	if err := addErr(node.Decode(s)); err != nil {
 		return err
 	}
 
-	return yaml.Unmarshal(data, s)
+	if hasAliases(reflect.TypeOf(s)) {
+		err := decodeAliases(yamlAliases{}, node, reflect.ValueOf(s).Elem(), FormatYAML, "", y.AliasPolicy)
+		if err := addErr(err); err != nil {
+			return err
+		}
+	}
+
+	if y.Strict {
+		yamlUnknownKeys(node, reflect.TypeOf(s), &errs)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	slices.SortStableFunc(errs, func(a, b string) int {
+		return cmp.Compare(messageLine(a), messageLine(b))
+	})
+
+	return &yaml.TypeError{Errors: errs}
+}
+
+// readSource reads r if it's not nil, or the config file at path otherwise.
+func readSource(path string, r io.Reader, logger *slog.Logger) ([]byte, error) {
+	switch {
+	case r != nil:
+		return io.ReadAll(r)
+	case path != "":
+		return readConfig(path, logger)
+	default:
+		return nil, ErrSourceNotSet
+	}
+}
+
+// fileFormat returns the format of a config file based on its extension.
+func fileFormat(path string) (Format, bool) {
+	switch {
+	case strings.HasSuffix(path, "toml"):
+		return FormatTOML, true
+	case strings.HasSuffix(path, "json"):
+		return FormatJSON, true
+	case strings.HasSuffix(path, "yml"), strings.HasSuffix(path, "yaml"):
+		return FormatYAML, true
+	default:
+		return "", false
+	}
+}
+
+// newFileLoader returns the file loader of the given format, reading from r
+// if it's not nil or from the file at path otherwise.
+func newFileLoader(format Format, path string, r io.Reader, strict bool) Loader {
+	return newSectionLoader(format, path, r, strict, fileSection{})
+}
+
+// fileSection selects the part of a config file loaded by a file loader,
+// i.e: the section of a profile, see ProfileLoader.
+type fileSection struct {
+	// path is the path of the table which is loaded, the root table if
+	// it's empty.
+	path []string
+
+	// skip is a key of the root table which is ignored.
+	skip string
+}
+
+// contains reports whether the key of the file is in the section.
+func (f fileSection) contains(key []string) bool {
+	if len(key) > 0 && len(f.path) == 0 && key[0] == f.skip {
+		return false
+	}
+
+	return len(key) > len(f.path) && slices.Equal(key[:len(f.path)], f.path)
 }
 
-func getConfig(path string) (*os.File, error) {
+// newSectionLoader is like newFileLoader but loads only the given section of
+// the file.
+func newSectionLoader(format Format, path string, r io.Reader, strict bool, section fileSection) Loader {
+	switch format {
+	case FormatTOML:
+		return &TOMLLoader{Path: path, Reader: r, Strict: strict, section: section}
+	case FormatJSON:
+		return &JSONLoader{Path: path, Reader: r, Strict: strict, section: section}
+	case FormatYAML:
+		return &YAMLLoader{Path: path, Reader: r, Strict: strict, section: section}
+	default:
+		return nil
+	}
+}
+
+// sectionValue returns the value at path in the table raw, or false if there
+// is none.
+func sectionValue(d aliasDecoder, raw any, path []string) (any, bool) {
+	for _, key := range path {
+		table, ok := d.table(raw)
+		if !ok {
+			return nil, false
+		}
+
+		if raw, ok = table[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return raw, true
+}
+
+// getConfig opens the config file at path, relative to the working
+// directory first. The paths which are tried are logged to logger, if it's
+// not nil.
+func getConfig(path string, logger *slog.Logger) (*os.File, error) {
 	pwd, err := os.Getwd()
 	if err != nil {
 		return nil, err
@@ -126,13 +456,360 @@ func getConfig(path string) (*os.File, error) {
 	}
 
 	// check if file with combined path is exists(relative path)
-	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+	_, err = os.Stat(configPath)
+	logTried(logger, configPath, !os.IsNotExist(err))
+	if !os.IsNotExist(err) {
 		return os.Open(configPath)
 	}
 
 	f, err := os.Open(path)
+	logTried(logger, path, !os.IsNotExist(err))
 	if os.IsNotExist(err) {
 		return nil, ErrFileNotFound
 	}
 	return f, err
 }
+
+func logTried(logger *slog.Logger, path string, found bool) {
+	if logger != nil {
+		logger.Debug("config file tried", "path", path, "found", found)
+	}
+}
+
+// tomlUnknownKeys returns an UnknownKeysError for the undecoded keys of the
+// section, if any. Children of undecoded tables are not reported, nor the
+// aliases of the fields of the type t, which are decoded separately.
+func tomlUnknownKeys(data []byte, undecoded []toml.Key, t reflect.Type, section fileSection) error {
+	var unknown []UnknownKey
+
+	reported := map[string]bool{}
+	for _, key := range undecoded {
+		if !section.contains(key) || tomlAliasKey(t, key[len(section.path):]) {
+			continue
+		}
+
+		if len(key) > 1 && reported[key[:len(key)-1].String()] {
+			reported[key.String()] = true
+			continue
+		}
+
+		reported[key.String()] = true
+		unknown = append(unknown, UnknownKey{
+			Key:  key.String(),
+			Line: tomlKeyLine(data, key),
+		})
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	return &UnknownKeysError{Keys: unknown}
+}
+
+// tomlAliasKey reports whether the key matches a field of the type t through
+// at least one alias.
+func tomlAliasKey(t reflect.Type, key toml.Key) bool {
+	alias := false
+	for _, k := range key {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+
+		if !isNestedStruct(t) {
+			return alias
+		}
+
+		sf, viaAlias, ok := aliasField(t, k, FormatTOML)
+		if !ok {
+			return false
+		}
+
+		t, alias = sf.Type, alias || viaAlias
+	}
+
+	return alias
+}
+
+// tomlKeyLine returns the line where key is defined, either as a table header
+// or as a key/value pair, or 0 if it can't be found.
+func tomlKeyLine(data []byte, key toml.Key) int {
+	table := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(line, "[] \t")
+			if tomlKeyEqual(table, key) {
+				return i + 1
+			}
+
+			continue
+		}
+
+		name, _, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+		if table != "" {
+			name = table + "." + name
+		}
+
+		if tomlKeyEqual(name, key) {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+func tomlKeyEqual(name string, key toml.Key) bool {
+	parts := strings.Split(name, ".")
+	if len(parts) != len(key) {
+		return false
+	}
+
+	for i, part := range parts {
+		if strings.Trim(strings.TrimSpace(part), `"'`) != key[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jsonUnknownKeys returns an UnknownKeysError for the keys of the value raw
+// of the section, which don't match any field of the type t, if any.
+func jsonUnknownKeys(data []byte, raw jsonValue, t reflect.Type, section fileSection) error {
+	var unknown []UnknownKey
+
+	w := &jsonWalker{data: data, offset: raw.offset, unknown: &unknown}
+	dec := json.NewDecoder(bytes.NewReader(raw.data))
+	if err := w.walk(dec, t, strings.Join(section.path, ".")); err != nil {
+		return err
+	}
+
+	unknown = slices.DeleteFunc(unknown, func(k UnknownKey) bool {
+		return !section.contains(strings.Split(k.Key, "."))
+	})
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	return &UnknownKeysError{Keys: unknown}
+}
+
+// jsonWalker reports the unknown keys of a JSON value of a file.
+type jsonWalker struct {
+	data    []byte
+	offset  int64 // offset of the value in data
+	unknown *[]UnknownKey
+}
+
+// walk reads the next JSON value of dec and reports its keys which don't
+// match any field of the type t. A nil type accepts any key.
+func (w *jsonWalker) walk(dec *json.Decoder, t reflect.Type, path string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// values decoded by their type itself can have any key
+	if t != nil && (reflect.PointerTo(t).Implements(jsonUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType)) {
+		t = nil
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+
+			key := tok.(string)
+			name := key
+			if path != "" {
+				name = path + "." + key
+			}
+
+			var child reflect.Type
+			if t != nil {
+				switch t.Kind() {
+				case reflect.Struct:
+					sf, ok := jsonField(t, key)
+					if !ok {
+						*w.unknown = append(*w.unknown, UnknownKey{
+							Key:  name,
+							Line: lineAt(w.data, w.offset+dec.InputOffset()),
+						})
+					}
+					child = sf.Type
+				case reflect.Map:
+					child = t.Elem()
+				}
+			}
+
+			if err := w.walk(dec, child, name); err != nil {
+				return err
+			}
+		}
+
+		_, err := dec.Token()
+		return err
+	case json.Delim('['):
+		var child reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			child = t.Elem()
+		}
+
+		for dec.More() {
+			if err := w.walk(dec, child, path); err != nil {
+				return err
+			}
+		}
+
+		_, err := dec.Token()
+		return err
+	}
+
+	return nil
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// jsonField returns the field of the struct type t the key is decoded into,
+// matching names case-insensitively like encoding/json does.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if sf.Anonymous && name == "" {
+			typ := sf.Type
+			if typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+
+			if typ.Kind() == reflect.Struct {
+				if f, ok := jsonField(typ, key); ok {
+					return f, true
+				}
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		if strings.EqualFold(name, key) {
+			return sf, true
+		}
+
+		for _, alias := range fieldAliases(sf.Tag.Get("aliases")) {
+			if strings.EqualFold(alias, key) {
+				return sf, true
+			}
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// yamlUnknownKeys adds an error for the keys of the YAML node n which don't
+// match any field of the type t, or any of their aliases, formatted like the
+// errors of the decoder in strict mode.
+func yamlUnknownKeys(n *yaml.Node, t reflect.Type, errs *[]string) {
+	n = yamlResolve(n)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// values decoded by their type itself can have any key
+	if reflect.PointerTo(t).Implements(yamlUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+
+	switch {
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Tag == "!!merge" {
+				continue
+			}
+
+			typ, ok := yamlFieldType(t, key.Value)
+			if !ok {
+				*errs = append(*errs, fmt.Sprintf("line %d: field %s not found in type %s", key.Line, key.Value, t))
+				continue
+			}
+
+			yamlUnknownKeys(value, typ, errs)
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 1; i < len(n.Content); i += 2 {
+			yamlUnknownKeys(n.Content[i], t.Elem(), errs)
+		}
+	case n.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, item := range n.Content {
+			yamlUnknownKeys(item, t.Elem(), errs)
+		}
+	}
+}
+
+var yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// yamlFieldType returns the type of the field of the struct type t the key
+// is decoded into, matching the names like yaml.v3 does, and the aliases of
+// the fields.
+func yamlFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	if sf, _, ok := aliasField(t, key, FormatYAML); ok && !strings.Contains(sf.Tag.Get("yaml"), ",inline") {
+		return sf.Type, true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() || !strings.Contains(sf.Tag.Get("yaml"), ",inline") {
+			continue
+		}
+
+		switch sf.Type.Kind() {
+		case reflect.Map:
+			return sf.Type.Elem(), true
+		case reflect.Struct:
+			if typ, ok := yamlFieldType(sf.Type, key); ok {
+				return typ, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// lineAt returns the line of the given offset in data.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -2,7 +2,10 @@ package multiconfig
 
 import (
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestYAML(t *testing.T) {
@@ -89,3 +92,23 @@ func TestJSON_Reader(t *testing.T) {
 // 	ExampleEnvironmentLoader()
 // 	ExampleTOMLLoader()
 // }
+
+func TestStrict(t *testing.T) {
+	var unknownErr *UnknownKeysError
+
+	l := &TOMLLoader{Reader: strings.NewReader("Name = \"koding\"\nFoo = 1\n"), Strict: true}
+	require.ErrorAs(t, l.Load(&Server{}), &unknownErr)
+	require.Equal(t, []UnknownKey{{Key: "Foo", Line: 2}}, unknownErr.Keys)
+
+	j := &JSONLoader{Reader: strings.NewReader(`{"name": "koding", "Labels": [1], "Foo": 1}`), Strict: true}
+	require.ErrorAs(t, j.Load(&Server{}), &unknownErr)
+	require.EqualError(t, unknownErr, `multiconfig: unknown keys "Foo"`)
+
+	y := &YAMLLoader{Reader: strings.NewReader("name: koding\nfoo: 1\n"), Strict: true}
+	require.Error(t, y.Load(&Server{}))
+
+	// unknown keys are ignored by default
+	require.NoError(t, (&TOMLLoader{Reader: strings.NewReader("Foo = 1\n")}).Load(&Server{}))
+	require.NoError(t, (&JSONLoader{Reader: strings.NewReader(`{"Foo": 1}`)}).Load(&Server{}))
+	require.NoError(t, (&YAMLLoader{Reader: strings.NewReader("foo: 1\n")}).Load(&Server{}))
+}
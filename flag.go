@@ -1,8 +1,11 @@
 package multiconfig
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"reflect"
 	"strings"
@@ -11,6 +14,11 @@ import (
 	"github.com/fatih/structs"
 )
 
+// ErrDuplicateFlag is returned by the FlagLoader when two fields generate the
+// same flag, i.e: with Flatten set, with CamelCase for the fields
+// "PostgresPort" and "Postgres.Port", or with aliases.
+var ErrDuplicateFlag = errors.New("multiconfig: duplicate flag")
+
 // FlagLoader satisfies the loader interface. It creates on the fly flags based
 // on the field names and parses them to load into the given pointer of struct
 // s.
@@ -24,9 +32,10 @@ type FlagLoader struct {
 	// Flatten doesn't add prefixes for nested structs. So previously if we had
 	// a nested struct `type T struct{Name struct{ ...}}`, this would generate
 	// --name-foo, --name-bar, etc. When Flatten is enabled, the flags will be
-	// flattend to the form: --foo, --bar, etc.. Panics if the nested structs
-	// has a duplicate field name in the root level of the struct (outer
-	// struct). Use this option only if you know what you do.
+	// flattend to the form: --foo, --bar, etc.. Loading returns an
+	// ErrDuplicateFlag error if a nested struct has a field with the same
+	// name as another field, like any other flag defined twice. Use this
+	// option only if you know what you do.
 	Flatten bool
 
 	// CamelCase adds a separator for field names in camelcase form. A
@@ -59,45 +68,151 @@ type FlagLoader struct {
 	// that will used in passed into the flag for Usage.
 	FlagUsageFunc func(name string) string
 
+	// Output is where the usage and the parsing errors are written. By
+	// default os.Stderr is used.
+	Output io.Writer
+
+	// UsageWidth is the width the usage is wrapped to. By default the value
+	// of the COLUMNS environment variable is used, or 80 if it's not set.
+	UsageWidth int
+
+	// ProfileFlag is the name of the flag selecting the profile of a
+	// ProfileLoader, without leading dashes. If set, the flag is accepted
+	// and documented, but it doesn't set any field.
+	ProfileFlag string
+
+	// Logger logs the decisions of the loader at the debug level. Nothing
+	// is logged if it's nil.
+	Logger *slog.Logger
+
+	// AliasPolicy defines what happens when a field is set by both its flag
+	// and one of the flags generated from its "aliases" tag.
+	AliasPolicy AliasPolicy
+
 	// only exists for testing.  This is the raw flagset that is to parse
 	flagSet *flag.FlagSet
+
+	// aliases holds the flags of the fields which have aliases
+	aliases []*flagAliases
+
+	// setErr is the error of the last flag which couldn't be set, as the
+	// flag set only reports its message
+	setErr *LoadError
 }
 
 // Load loads the source into the config defined by struct s
 func (f *FlagLoader) Load(s any) error {
+	if err := f.defineFlags(s); err != nil {
+		return err
+	}
+
+	flagSet := f.flagSet
+	flagSet.SetOutput(f.output())
+	flagSet.Usage = func() {
+		// the usage is best effort, errors are reported by Parse
+		_ = f.WriteUsage(f.output(), s)
+	}
+
+	args := filterArgs(os.Args[1:])
+	if f.Args != nil {
+		args = f.Args
+	}
+
+	f.setErr = nil
+	if err := flagSet.Parse(args); err != nil {
+		if f.setErr != nil {
+			return f.setErr
+		}
+
+		return &LoadError{Loader: "flags", Err: err}
+	}
+
+	return f.setAliases()
+}
+
+// setAliases sets the fields from the values of their alias flags, according
+// to the AliasPolicy.
+func (f *FlagLoader) setAliases() error {
+	set := map[string]bool{}
+	f.flagSet.Visit(func(fl *flag.Flag) {
+		set[fl.Name] = true
+	})
+
+	for _, a := range f.aliases {
+		names := make([]string, len(a.names))
+		isSet := make([]bool, len(a.names))
+		for i, name := range a.names {
+			names[i], isSet[i] = "-"+name, set[name]
+		}
+
+		chosen, err := f.AliasPolicy.choose(names, isSet)
+		if err != nil {
+			return &LoadError{Loader: "flags", Field: strings.Join(a.value.path, "."), Err: err}
+		}
+
+		// the flag of the field itself is set during parsing
+		if chosen <= 0 {
+			continue
+		}
+
+		if err := fieldSet(a.value.field, a.values[chosen-1].value); err != nil {
+			return &LoadError{
+				Loader: "flags",
+				Source: names[chosen],
+				Field:  strings.Join(a.value.path, "."),
+				Err:    err,
+			}
+		}
+
+		f.logParsed(a.names[chosen], a.value, a.values[chosen-1].value)
+	}
+
+	return nil
+}
+
+// logParsed logs the value val of the flag name, set to the field of v.
+func (f *FlagLoader) logParsed(name string, v *fieldValue, val string) {
+	if f.Logger == nil {
+		return
+	}
+
+	f.Logger.Debug("flag parsed",
+		"flag", "-"+name,
+		"field", strings.Join(v.path, "."),
+		"value", logValue(val, isSecretField(v.field)),
+	)
+}
+
+// defineFlags creates a new flag set with the flags of the config struct s.
+func (f *FlagLoader) defineFlags(s any) error {
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
 	if f.StructSeparator == "" {
 		f.StructSeparator = "-"
 	}
 	strct := structs.New(s)
 	structName := strct.Name()
 
-	flagSet := flag.NewFlagSet(structName, f.ErrorHandling)
-	f.flagSet = flagSet
+	f.flagSet = flag.NewFlagSet(structName, f.ErrorHandling)
+	f.aliases = nil
 
 	for _, field := range strct.Fields() {
-		if err := f.processField(f.Prefix, field); err != nil {
+		if err := f.processField([]string{f.Prefix}, nil, field); err != nil {
 			return err
 		}
 	}
 
-	flagSet.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
-		flagSet.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nGenerated environment variables:\n")
-		e := &EnvironmentLoader{
-			Prefix:    f.EnvPrefix,
-			CamelCase: f.CamelCase,
+	if f.ProfileFlag != "" {
+		if f.flagSet.Lookup(f.ProfileFlag) != nil {
+			return fmt.Errorf("%w: flag '%s' of the profile is already defined by a field", ErrDuplicateFlag, f.ProfileFlag)
 		}
-		e.PrintEnvs(s)
-		fmt.Println("")
-	}
 
-	args := filterArgs(os.Args[1:])
-	if f.Args != nil {
-		args = f.Args
+		f.flagSet.String(f.ProfileFlag, "", "Name of the configuration profile.")
 	}
 
-	return flagSet.Parse(args)
+	return nil
 }
 
 func filterArgs(args []string) []string {
@@ -115,52 +230,76 @@ func filterArgs(args []string) []string {
 	return r
 }
 
-// processField generates a flag based on the given prefix and field. If a
-// nested struct is detected, a flag for each field of that nested struct is
-// generated too.
-// panics if it tries to generate duplicate flags (can only happen when Flatten is set)
-func (f *FlagLoader) processField(prefix string, field *structs.Field) error {
+// processField generates a flag based on the given prefixes and field. The
+// first prefix is the one of the field's name, the others come from the
+// aliases of its parents. If a nested struct is detected, a flag for each
+// field of that nested struct is generated too. An ErrDuplicateFlag error is
+// returned if it tries to generate a flag which is already defined, by its
+// name or one of its aliases.
+func (f *FlagLoader) processField(prefixes []string, path []string, field *structs.Field) error {
 	if !field.IsExported() {
 		return nil
 	}
 
-	if prefix != "" {
-		prefix = fmt.Sprintf("%s%s", prefix, f.StructSeparator)
+	path = append(path[:len(path):len(path)], field.Name())
+
+	fieldNames := append([]string{field.Name()}, fieldAliases(field.Tag("aliases"))...)
+	if f.CamelCase {
+		for i, name := range fieldNames {
+			fieldNames[i] = strings.Join(camelcase.Split(name), "-")
+		}
+	}
+
+	names := []string{}
+	for _, prefix := range prefixes {
+		if prefix != "" {
+			prefix = fmt.Sprintf("%s%s", prefix, f.StructSeparator)
+		}
+
+		for _, fieldName := range fieldNames {
+			names = append(names, fmt.Sprintf("%s%s", prefix, fieldName))
+		}
 	}
 
 	switch field.Kind() {
 	case reflect.Struct:
 		for _, ff := range field.Fields() {
 			if f.Flatten {
-				if err := f.processField(prefix, ff); err != nil {
+				if err := f.processField(prefixes, path, ff); err != nil {
 					return err
 				}
 				continue
 			}
-			fieldName := field.Name()
-			if f.CamelCase {
-				fieldName = strings.Join(camelcase.Split(fieldName), "-")
-			}
-			if err := f.processField(fmt.Sprintf("%s%s", prefix, fieldName), ff); err != nil {
+			if err := f.processField(names, path, ff); err != nil {
 				return err
 			}
 		}
 	default:
-		fieldName := field.Name()
-		if f.CamelCase {
-			fieldName = strings.Join(camelcase.Split(fieldName), "-")
-		}
-		fName := flagName(fmt.Sprintf("%s%s", prefix, fieldName))
-		if f.Flatten {
-			// Check if the flag is already defined
-			// Panic with a helpful message if it is
-			f.flagSet.VisitAll(func(fl *flag.Flag) {
-				if fName == fl.Name {
-					panic(fmt.Sprintf("flag '%s' is already defined in an outer struct", fl.Name))
-				}
-			})
+		for i, name := range names {
+			names[i] = flagName(name)
+		}
+
+		for _, fName := range names {
+			if f.flagSet.Lookup(fName) != nil {
+				return fmt.Errorf("%w: flag '%s' of the field '%s' is already defined", ErrDuplicateFlag, fName, strings.Join(path, "."))
+			}
 		}
-		f.flagSet.Var(newFieldValue(field), fName, f.flagUsage(fieldName, field))
+
+		value := newFieldValue(field, path)
+		value.loader, value.flag = f, names[0]
+		f.flagSet.Var(value, names[0], f.flagUsage(fieldNames[0], field))
+
+		if len(names) == 1 {
+			return nil
+		}
+
+		a := &flagAliases{value: value, names: names}
+		for _, name := range names[1:] {
+			av := &aliasValue{field: value}
+			a.values = append(a.values, av)
+			f.flagSet.Var(av, name, fmt.Sprintf("Alias of -%s.", names[0]))
+		}
+		f.aliases = append(f.aliases, a)
 	}
 
 	return nil
@@ -182,16 +321,39 @@ func (f *FlagLoader) flagUsage(fieldName string, field *structs.Field) string {
 // fieldValue satisfies the flag.Value and flag.Getter interfaces
 type fieldValue struct {
 	field *structs.Field
+
+	// path holds the names of the field and its parents
+	path []string
+
+	// loader and flag are used to report the errors and log the values of
+	// Set, if set
+	loader *FlagLoader
+	flag   string
 }
 
-func newFieldValue(f *structs.Field) *fieldValue {
+func newFieldValue(f *structs.Field, path []string) *fieldValue {
 	return &fieldValue{
 		field: f,
+		path:  path,
 	}
 }
 
 func (f *fieldValue) Set(val string) error {
-	return fieldSet(f.field, val)
+	err := fieldSet(f.field, val)
+	if err != nil && f.loader != nil {
+		f.loader.setErr = &LoadError{
+			Loader: "flags",
+			Source: "-" + f.flag,
+			Field:  strings.Join(f.path, "."),
+			Err:    err,
+		}
+	}
+
+	if err == nil && f.loader != nil {
+		f.loader.logParsed(f.flag, f, val)
+	}
+
+	return err
 }
 
 func (f *fieldValue) String() string {
@@ -199,7 +361,22 @@ func (f *fieldValue) String() string {
 		return ""
 	}
 
-	return fmt.Sprintf("%v", f.field.Value())
+	if isSecretField(f.field) && !f.field.IsZero() {
+		return redacted
+	}
+
+	value := f.field.Value()
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+
+		if _, ok := value.(fmt.Stringer); !ok {
+			value = v.Elem().Interface()
+		}
+	}
+
+	return fmt.Sprintf("%v", value)
 }
 
 func (f *fieldValue) Get() any {
@@ -217,7 +394,49 @@ func (f *fieldValue) IsZero() bool {
 // This is an unexported interface, be careful about it.
 // https://code.google.com/p/go/source/browse/src/pkg/flag/flag.go?name=release#101
 func (f *fieldValue) IsBoolFlag() bool {
-	return f.field.Kind() == reflect.Bool
+	t := reflect.TypeOf(f.field.Value())
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Bool
+}
+
+// flagAliases holds the flags of a field which has aliases.
+type flagAliases struct {
+	value *fieldValue
+
+	// names holds the name of the flag of the field followed by the names
+	// of its aliases
+	names []string
+
+	// values holds the values of the alias flags, in the order of names[1:]
+	values []*aliasValue
+}
+
+// aliasValue is the flag.Value of an alias flag. The value is only recorded
+// during parsing, it's set to the field once the AliasPolicy is applied.
+type aliasValue struct {
+	field *fieldValue
+	value string
+}
+
+func (a *aliasValue) Set(val string) error {
+	a.value = val
+	return nil
+}
+
+func (a *aliasValue) String() string {
+	if a.field == nil {
+		return ""
+	}
+
+	return a.field.String()
+}
+
+// This is an unexported interface, be careful about it.
+func (a *aliasValue) IsBoolFlag() bool {
+	return a.field.IsBoolFlag()
 }
 
 func flagName(name string) string { return strings.ToLower(name) }
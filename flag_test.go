@@ -337,3 +337,37 @@ func getFlags(t *testing.T, structName, prefix string) []string {
 
 	return args
 }
+
+func TestFlattenDuplicateFlag(t *testing.T) {
+	type Server struct {
+		Name     string
+		Postgres struct {
+			Name string
+		}
+	}
+
+	m := &FlagLoader{Flatten: true, Args: []string{}}
+	err := m.Load(&Server{})
+	require.ErrorIs(t, err, ErrDuplicateFlag)
+	require.EqualError(t, err, "multiconfig: duplicate flag: flag 'name' of the field 'Postgres.Name' is already defined")
+}
+
+func TestDuplicateFlag(t *testing.T) {
+	type CamelServer struct {
+		PostgresPort int
+		Postgres     struct {
+			Port int
+		}
+	}
+
+	err := (&FlagLoader{CamelCase: true, Args: []string{}}).Load(&CamelServer{})
+	require.EqualError(t, err, "multiconfig: duplicate flag: flag 'postgres-port' of the field 'Postgres.Port' is already defined")
+
+	type AliasedServer struct {
+		Host    string
+		Address string `aliases:"Host"`
+	}
+
+	err = (&FlagLoader{Args: []string{}}).Load(&AliasedServer{})
+	require.EqualError(t, err, "multiconfig: duplicate flag: flag 'host' of the field 'Address' is already defined")
+}
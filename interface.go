@@ -1,7 +1,6 @@
 package multiconfig
 
 import (
-	"errors"
 	"reflect"
 )
 
@@ -45,13 +44,16 @@ func structFields(v reflect.Value) []reflect.Value {
 }
 
 // Load will populate s by recursively calling the `ApplyDefaults` method on it
+// Unlike the other loaders, s can point to any type implementing
+// DefaultValues, but errors are still ErrNotStructPointer if it's not a
+// non-nil pointer.
 func (l *InterfaceLoader) Load(s any) error {
 	v := reflect.ValueOf(s)
 	if v.Kind() != reflect.Pointer {
-		return errors.New("cannot load into a value: target must be a pointer")
+		return &notStructPointerError{msg: "cannot load into a value: target must be a pointer"}
 	}
 	if v.IsNil() {
-		return errors.New("cannot load into a nil pointer")
+		return &notStructPointerError{msg: "cannot load into a nil pointer"}
 	}
 	l.processValue(v)
 	return nil
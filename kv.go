@@ -0,0 +1,226 @@
+package multiconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/fatih/camelcase"
+	"github.com/fatih/structs"
+)
+
+// KVBackend is a key/value store read by a KVLoader, i.e: ConsulBackend.
+type KVBackend interface {
+	// List returns the values of the keys starting with prefix, keyed by
+	// their full key.
+	List(ctx context.Context, prefix string) (map[string]string, error)
+}
+
+// KVWatcher is implemented by the backends which can wait for changes, see
+// KVLoader.Watch.
+type KVWatcher interface {
+	KVBackend
+
+	// Watch is like List but, if index is not 0, it blocks until the keys
+	// starting with prefix change after index, or until a timeout of the
+	// backend. It returns the values and the current index of the keys,
+	// which must not be 0.
+	Watch(ctx context.Context, prefix string, index uint64) (map[string]string, uint64, error)
+}
+
+// KVLoader satisfies the loader interface. It loads the configuration from
+// the keys of a key/value store in the form of {PREFIX}/{FIELDNAME}, i.e:
+// app/postgres/port for the field Postgres.Port with the prefix "app". The
+// keys are generated like the names of the environment variables of the
+// EnvironmentLoader, in lowercase and separated by slashes. Keys with an
+// empty value are ignored.
+type KVLoader struct {
+	// Backend is the key/value store the keys are read from.
+	Backend KVBackend
+
+	// Prefix is prepended to every key, i.e: "app" or "services/app". By
+	// default it's the name of the struct in lowercase.
+	Prefix string
+
+	// CamelCase adds a separator for field names in camelcase form. A
+	// fieldname of "AccessKey" would generate the key "{PREFIX}/accesskey".
+	// If CamelCase is enabled, the key will be "{PREFIX}/access_key".
+	CamelCase bool
+
+	// AliasPolicy defines what happens when a field is set by both its key
+	// and one of the keys generated from its "aliases" tag.
+	AliasPolicy AliasPolicy
+
+	// Logger logs the decisions of the loader at the debug level. Nothing
+	// is logged if it's nil.
+	Logger *slog.Logger
+}
+
+// Load loads the source into the config defined by struct s
+func (k *KVLoader) Load(s any) error {
+	return k.LoadContext(context.Background(), s)
+}
+
+// LoadContext is like Load but stops as soon as ctx is done.
+func (k *KVLoader) LoadContext(ctx context.Context, s any) error {
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
+	if k.Backend == nil {
+		return &LoadError{Loader: "kv store", Err: errors.New("no backend")}
+	}
+
+	prefix := k.getPrefix(s)
+
+	pairs, err := k.Backend.List(ctx, prefix+"/")
+	if err != nil {
+		return &LoadError{Loader: "kv store", Source: prefix, Err: err}
+	}
+
+	return k.load(s, prefix, pairs)
+}
+
+// Watch calls onChange every time the keys of the config defined by struct s
+// change in the backend, which must implement KVWatcher, until ctx is done.
+// It's meant to reload a Store:
+//
+//	go kv.Watch(ctx, &Config{}, func() { store.Reload() })
+//
+// Watch returns the error of the backend, or the error of ctx once it's done.
+func (k *KVLoader) Watch(ctx context.Context, s any, onChange func()) error {
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
+	w, ok := k.Backend.(KVWatcher)
+	if !ok {
+		return &LoadError{Loader: "kv store", Err: fmt.Errorf("%T can't watch for changes", k.Backend)}
+	}
+
+	prefix := k.getPrefix(s)
+
+	var index uint64
+	for {
+		_, next, err := w.Watch(ctx, prefix+"/", index)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+
+			return &LoadError{Loader: "kv store", Source: prefix, Err: err}
+		}
+
+		// an index of 0 would make the next call return immediately, and
+		// the loop spin
+		if next == 0 {
+			return &LoadError{Loader: "kv store", Source: prefix, Err: errors.New("backend returned an index of 0")}
+		}
+
+		changed := index != 0 && next != index
+
+		// the index is reset if it goes backwards, i.e: after a snapshot
+		// restore
+		if next < index {
+			next = 0
+		}
+
+		index = next
+
+		if changed {
+			if k.Logger != nil {
+				k.Logger.Debug("kv store changed", "prefix", prefix, "index", index)
+			}
+
+			onChange()
+		}
+	}
+}
+
+func (k *KVLoader) getPrefix(s any) string {
+	if k.Prefix != "" {
+		return strings.TrimSuffix(k.Prefix, "/")
+	}
+
+	return strings.ToLower(structs.Name(s))
+}
+
+// load sets the fields of s from the key/value pairs.
+func (k *KVLoader) load(s any, prefix string, pairs map[string]string) error {
+	strct := structs.New(s)
+	for key, val := range strct.Map() {
+		if err := k.processField([]string{prefix}, nil, strct.Field(key), key, val, pairs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processField generates the keys of the field like
+// EnvironmentLoader.processField generates the names of its environment
+// variables, and sets the field from pairs.
+func (k *KVLoader) processField(prefixes []string, path []string, field *structs.Field, name string, strctMap any, pairs map[string]string) error {
+	path = append(path[:len(path):len(path)], field.Name())
+
+	keys := []string{}
+	for _, prefix := range prefixes {
+		for _, n := range append([]string{name}, fieldAliases(field.Tag("aliases"))...) {
+			keys = append(keys, k.generateKey(prefix, n))
+		}
+	}
+
+	switch smap := strctMap.(type) {
+	case map[string]any:
+		for key, val := range smap {
+			if err := k.processField(keys, path, field.Field(key), key, val, pairs); err != nil {
+				return err
+			}
+		}
+	default:
+		set := make([]bool, len(keys))
+		for i, key := range keys {
+			set[i] = pairs[key] != ""
+		}
+
+		chosen, err := k.AliasPolicy.choose(keys, set)
+		if err != nil {
+			return &LoadError{Loader: "kv store", Field: strings.Join(path, "."), Err: err}
+		}
+
+		if chosen < 0 {
+			return nil
+		}
+
+		key, val := keys[chosen], pairs[keys[chosen]]
+		if k.Logger != nil {
+			k.Logger.Debug("kv key found",
+				"key", key,
+				"field", strings.Join(path, "."),
+				"value", logValue(val, isSecretField(field)),
+			)
+		}
+
+		if err := fieldSet(field, val); err != nil {
+			return &LoadError{
+				Loader: "kv store",
+				Source: key,
+				Field:  strings.Join(path, "."),
+				Err:    err,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (k *KVLoader) generateKey(prefix string, name string) string {
+	key := strings.ToLower(name)
+	if k.CamelCase {
+		key = strings.ToLower(strings.Join(camelcase.Split(name), "_"))
+	}
+
+	return prefix + "/" + key
+}
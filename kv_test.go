@@ -0,0 +1,111 @@
+package multiconfig
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mapBackend is a KVBackend holding the pairs in memory.
+type mapBackend map[string]string
+
+func (m mapBackend) List(ctx context.Context, prefix string) (map[string]string, error) {
+	pairs := map[string]string{}
+	for key, val := range m {
+		if strings.HasPrefix(key, prefix) {
+			pairs[key] = val
+		}
+	}
+
+	return pairs, nil
+}
+
+func TestKVLoader(t *testing.T) {
+	k := &KVLoader{Backend: mapBackend{
+		"server/name":           "koding",
+		"server/port":           "4000",
+		"server/users":          "ankara,istanbul",
+		"server/postgres/port":  "5432",
+		"server/postgres/hosts": "db1,db2",
+		"server/enabled":        "",
+		"other/name":            "ignored",
+	}}
+
+	s := &Server{}
+	require.NoError(t, k.Load(s))
+	require.Equal(t, "koding", s.Name)
+	require.Equal(t, 4000, s.Port)
+	require.Equal(t, []string{"ankara", "istanbul"}, s.Users)
+	require.Equal(t, uint16(5432), s.Postgres.Port)
+	require.Equal(t, []string{"db1", "db2"}, s.Postgres.Hosts)
+	require.False(t, s.Enabled)
+}
+
+func TestKVLoaderPrefix(t *testing.T) {
+	backend := mapBackend{
+		"services/app/access_key":         "key",
+		"services/app/availability_ratio": "0.5",
+	}
+
+	s := &CamelCaseServer{}
+	require.NoError(t, (&KVLoader{Backend: backend, Prefix: "services/app/", CamelCase: true}).Load(s))
+	require.Equal(t, &CamelCaseServer{AccessKey: "key", AvailabilityRatio: 0.5}, s)
+}
+
+func TestKVLoaderAliases(t *testing.T) {
+	s := &AliasServer{}
+	require.NoError(t, (&KVLoader{Backend: mapBackend{"aliasserver/pg/db": "configdb"}}).Load(s))
+	require.Equal(t, "configdb", s.Postgres.Database)
+
+	backend := mapBackend{
+		"aliasserver/postgres/database": "configdb",
+		"aliasserver/pg/dbname":         "other",
+	}
+
+	err := (&KVLoader{Backend: backend}).Load(&AliasServer{})
+	require.EqualError(t, err, "multiconfig: kv store: field 'Postgres.Database': both aliasserver/postgres/database and aliasserver/pg/dbname are set")
+}
+
+func TestKVLoaderErrors(t *testing.T) {
+	err := (&KVLoader{Backend: mapBackend{"server/port": "port"}}).Load(&Server{})
+
+	var loadErr *LoadError
+	require.ErrorAs(t, err, &loadErr)
+	require.Equal(t, "kv store", loadErr.Loader)
+	require.Equal(t, "server/port", loadErr.Source)
+	require.Equal(t, "Port", loadErr.Field)
+
+	err = (&KVLoader{Backend: failingBackend{}}).Load(&Server{})
+	require.EqualError(t, err, "multiconfig: kv store server: unreachable")
+
+	err = (&KVLoader{}).Load(&Server{})
+	require.EqualError(t, err, "multiconfig: kv store: no backend")
+
+	err = (&KVLoader{Backend: mapBackend{}}).Load(Server{})
+	require.ErrorIs(t, err, ErrNotStructPointer)
+
+	err = (&KVLoader{Backend: mapBackend{}}).Watch(context.Background(), &Server{}, func() {})
+	require.EqualError(t, err, "multiconfig: kv store: multiconfig.mapBackend can't watch for changes")
+
+	err = (&KVLoader{Backend: zeroIndexBackend{}}).Watch(context.Background(), &Server{}, func() {})
+	require.EqualError(t, err, "multiconfig: kv store server: backend returned an index of 0")
+}
+
+// zeroIndexBackend is a KVWatcher which always returns an index of 0.
+type zeroIndexBackend struct {
+	mapBackend
+}
+
+func (z zeroIndexBackend) Watch(ctx context.Context, prefix string, index uint64) (map[string]string, uint64, error) {
+	pairs, err := z.List(ctx, prefix)
+	return pairs, 0, err
+}
+
+type failingBackend struct{}
+
+func (failingBackend) List(ctx context.Context, prefix string) (map[string]string, error) {
+	return nil, errors.New("unreachable")
+}
@@ -0,0 +1,186 @@
+package multiconfig
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Option configures Load.
+type Option func(*options)
+
+type options struct {
+	ctx        context.Context
+	files      []Loader
+	err        error
+	envPrefix  string
+	flagPrefix string
+	args       []string
+	validators []Validator
+	provenance Provenance
+	logger     *slog.Logger
+	secrets    bool
+	constrain  bool
+}
+
+// WithContext bounds the loading with ctx, see DefaultLoader.LoadContext.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithPath loads the config files at the given paths after the default
+// values, in order. The format of each file is defined by its extension.
+// HTTP(S) URLs are fetched with a URLLoader, use WithURLLoader to configure
+// it.
+func WithPath(paths ...string) Option {
+	return func(o *options) {
+		for _, path := range paths {
+			if isURL(path) {
+				o.files = append(o.files, &URLLoader{URL: path})
+				continue
+			}
+
+			format, ok := fileFormat(path)
+			if !ok {
+				if o.err == nil {
+					o.err = fmt.Errorf("multiconfig: unsupported config file %s", path)
+				}
+				continue
+			}
+
+			o.files = append(o.files, newFileLoader(format, path, nil, false))
+		}
+	}
+}
+
+// WithURLLoader loads the config file fetched by u after the default values,
+// in the order of the config files given with WithPath, i.e: to send headers
+// or to trust an internal CA.
+func WithURLLoader(u *URLLoader) Option {
+	return func(o *options) {
+		o.files = append(o.files, u)
+	}
+}
+
+// WithEnvPrefix sets the prefix of the environment variables, see
+// EnvironmentLoader.Prefix.
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithFlagPrefix sets the prefix of the flags, see FlagLoader.Prefix.
+func WithFlagPrefix(prefix string) Option {
+	return func(o *options) {
+		o.flagPrefix = prefix
+	}
+}
+
+// WithArgs sets the arguments the flags are parsed from. By default
+// os.Args[1:] is used.
+func WithArgs(args []string) Option {
+	return func(o *options) {
+		o.args = args
+	}
+}
+
+// WithValidators validates the loaded config with the given validators, after
+// the RequiredValidator and the ConstraintValidator if WithConstraints is
+// given.
+func WithValidators(validators ...Validator) Option {
+	return func(o *options) {
+		o.validators = append(o.validators, validators...)
+	}
+}
+
+// WithConstraints validates the loaded config against the constraints of its
+// tags, see ConstraintValidator.
+func WithConstraints() Option {
+	return func(o *options) {
+		o.constrain = true
+	}
+}
+
+// WithProvenance records in p which source set each field, see Provenance.
+func WithProvenance(p Provenance) Option {
+	return func(o *options) {
+		o.provenance = p
+	}
+}
+
+// WithSecrets resolves the secret references of the loaded config, see
+// SecretLoader. They are left untouched by default.
+func WithSecrets() Option {
+	return func(o *options) {
+		o.secrets = true
+	}
+}
+
+// WithLogger logs the loading at the debug level, see DefaultLoader.Logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// Load returns a new config of type T, which must be a struct, loaded from
+// the default sources like DefaultLoader does: the default values, the
+// config files given with WithPath, the environment variables and the flags,
+// and the secret references if WithSecrets is given. The config is validated
+// before it's returned.
+//
+//	cfg, err := multiconfig.Load[Server](multiconfig.WithPath("config.toml"))
+func Load[T any](opts ...Option) (*T, error) {
+	o := &options{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s := new(T)
+	if err := checkStructPointer(s); err != nil {
+		return nil, err
+	}
+
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	loaders := append([]Loader{&TagLoader{}}, o.files...)
+	loaders = append(loaders,
+		&EnvironmentLoader{Prefix: o.envPrefix},
+		&FlagLoader{Prefix: o.flagPrefix, EnvPrefix: o.envPrefix, Args: o.args},
+	)
+
+	if o.secrets {
+		loaders = append(loaders, &SecretLoader{})
+	}
+
+	if o.provenance == nil {
+		o.provenance = Provenance{}
+	}
+
+	validators := []Validator{&RequiredValidator{}}
+	if o.constrain {
+		validators = append(validators, &ConstraintValidator{})
+	}
+
+	d := &DefaultLoader{
+		Loader:     TrackProvenance(o.provenance, loaders...),
+		Validator:  MultiValidator(append(validators, o.validators...)...),
+		Provenance: o.provenance,
+		Logger:     o.logger,
+	}
+
+	if err := d.LoadContext(o.ctx, s); err != nil {
+		return nil, err
+	}
+
+	if err := d.Validate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,70 @@
+package multiconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGeneric(t *testing.T) {
+	p := Provenance{}
+	s, err := Load[Server](WithPath(testTOML), WithArgs([]string{}), WithProvenance(p))
+	require.NoError(t, err)
+	testStruct(t, s, getDefaultServer())
+	require.Equal(t, "toml file "+testTOML, p["Name"])
+
+	t.Setenv("APP_PORT", "8080")
+
+	s, err = Load[Server](
+		WithPath(testJSON),
+		WithEnvPrefix("app"),
+		WithFlagPrefix("app"),
+		WithArgs([]string{"-app-name", "flag"}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "flag", s.Name)
+	require.Equal(t, 8080, s.Port)
+
+	// required fields are validated
+	_, err = Load[Server](WithArgs([]string{}))
+	require.EqualError(t, err, "multiconfig: field 'Name' is required")
+
+	errInvalid := errors.New("invalid")
+	_, err = Load[Server](WithPath(testTOML), WithArgs([]string{}), WithValidators(validatorFunc(func(s any) error {
+		return errInvalid
+	})))
+	require.ErrorIs(t, err, errInvalid)
+
+	_, err = Load[Server](WithPath("config.ini"))
+	require.EqualError(t, err, "multiconfig: unsupported config file config.ini")
+
+	_, err = Load[int]()
+	require.ErrorIs(t, err, ErrNotStructPointer)
+}
+
+func TestNotStructPointer(t *testing.T) {
+	loaders := []Loader{
+		&TagLoader{},
+		&TOMLLoader{Reader: strings.NewReader("")},
+		&JSONLoader{Reader: strings.NewReader("{}")},
+		&YAMLLoader{Reader: strings.NewReader("")},
+		&EnvironmentLoader{},
+		&FlagLoader{Args: []string{}},
+		&SecretLoader{},
+		&InterfaceLoader{},
+		&ProfileLoader{Path: testTOML},
+	}
+
+	var nilServer *Server
+	for _, loader := range loaders {
+		for _, s := range []any{Server{}, nilServer, nil} {
+			err := loader.Load(s)
+			require.ErrorIs(t, err, ErrNotStructPointer, "%T with %T", loader, s)
+		}
+	}
+
+	err := (&TagLoader{}).Load(new(int))
+	require.EqualError(t, err, "multiconfig: *int is not a non-nil pointer to a struct")
+}
@@ -0,0 +1,167 @@
+package multiconfig
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// The loaders log their decisions with a *slog.Logger at the debug level, so
+// the loading of the configuration can be followed at startup. The values of
+// the secrets (see Secret) are redacted.
+
+// inheritLogger is implemented by the built-in loaders, so the Logger of a
+// DefaultLoader is used by its loaders which don't have their own. The logger
+// is stored in the loaders, it's not reset after the load.
+type inheritLogger interface {
+	inheritLogger(l *slog.Logger)
+}
+
+// setLogger sets the logger of the loader l if it doesn't have one. The
+// Logger field of l is modified, so l keeps the logger afterwards.
+func setLogger(l Loader, logger *slog.Logger) {
+	if i, ok := l.(inheritLogger); ok && logger != nil {
+		i.inheritLogger(logger)
+	}
+}
+
+// logValue returns the value v of a field as it's logged.
+func logValue(v any, secret bool) any {
+	if secret && v != nil && !reflect.ValueOf(v).IsZero() {
+		return redacted
+	}
+
+	return v
+}
+
+// logChanges logs the fields of s which have changed since the snapshot
+// before was taken, see snapshot.
+func logChanges(l *slog.Logger, msg string, s any, before map[string]any, attrs ...any) {
+	if l == nil {
+		return
+	}
+
+	for _, field := range leafFields(configFields(reflect.ValueOf(s), nil)) {
+		name := field.Name()
+		val := field.Value.Interface()
+		if old, ok := before[name]; ok && reflect.DeepEqual(old, val) {
+			continue
+		}
+
+		args := append([]any{"field", name, "value", logValue(val, isSecret(field.Field))}, attrs...)
+		l.Debug(msg, args...)
+	}
+}
+
+func (t *TagLoader) inheritLogger(l *slog.Logger) {
+	if t.Logger == nil {
+		t.Logger = l
+	}
+}
+
+func (t *TOMLLoader) inheritLogger(l *slog.Logger) {
+	if t.Logger == nil {
+		t.Logger = l
+	}
+}
+
+func (j *JSONLoader) inheritLogger(l *slog.Logger) {
+	if j.Logger == nil {
+		j.Logger = l
+	}
+}
+
+func (y *YAMLLoader) inheritLogger(l *slog.Logger) {
+	if y.Logger == nil {
+		y.Logger = l
+	}
+}
+
+func (e *EnvironmentLoader) inheritLogger(l *slog.Logger) {
+	if e.Logger == nil {
+		e.Logger = l
+	}
+}
+
+func (f *FlagLoader) inheritLogger(l *slog.Logger) {
+	if f.Logger == nil {
+		f.Logger = l
+	}
+}
+
+func (l *SecretLoader) inheritLogger(logger *slog.Logger) {
+	if l.Logger == nil {
+		l.Logger = logger
+	}
+}
+
+func (p *ProfileLoader) inheritLogger(l *slog.Logger) {
+	if p.Logger == nil {
+		p.Logger = l
+	}
+}
+
+func (k *KVLoader) inheritLogger(l *slog.Logger) {
+	if k.Logger == nil {
+		k.Logger = l
+	}
+}
+
+func (u *URLLoader) inheritLogger(l *slog.Logger) {
+	if u.Logger == nil {
+		u.Logger = l
+	}
+}
+
+func (d *DirectoryLoader) inheritLogger(l *slog.Logger) {
+	if d.Logger == nil {
+		d.Logger = l
+	}
+}
+
+func (m multiLoader) inheritLogger(l *slog.Logger) {
+	for _, loader := range m {
+		setLogger(loader, l)
+	}
+}
+
+func (p *provenanceLoader) inheritLogger(l *slog.Logger) {
+	for _, loader := range p.loaders {
+		setLogger(loader, l)
+	}
+}
+
+// logFields logs the source of each field of s which is set, according to
+// provenance.
+func logFields(l *slog.Logger, s any, provenance Provenance) {
+	if l == nil || provenance == nil {
+		return
+	}
+
+	for _, field := range leafFields(configFields(reflect.ValueOf(s), nil)) {
+		name := field.Name()
+		source, ok := provenance[name]
+		if !ok {
+			continue
+		}
+
+		secret := isSecret(field.Field) || isResolvedSecret(source) || isFileSource(source)
+		l.Debug("field loaded", "field", name, "source", source, "value", logValue(field.Value.Interface(), secret))
+	}
+}
+
+// isFileSource reports whether a provenance source is a {NAME}_FILE
+// environment variable or a directory, whose files are redacted like
+// secrets.
+func isFileSource(source string) bool {
+	if strings.HasPrefix(source, "directory ") {
+		return true
+	}
+
+	return strings.HasPrefix(source, "environment ") && strings.HasSuffix(source, "_FILE")
+}
+
+// isResolvedSecret reports whether a provenance source is a secret resolver.
+func isResolvedSecret(source string) bool {
+	return strings.HasSuffix(source, "secret resolver")
+}
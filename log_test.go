@@ -0,0 +1,148 @@
+package multiconfig
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger() (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), &buf
+}
+
+func TestLogger(t *testing.T) {
+	t.Setenv("SECRETSERVER_PASSWORD", "s3cr3t")
+	t.Setenv("SECRETSERVER_NAME", "env://LOG_NAME")
+	t.Setenv("LOG_NAME", "r3s0lv3d")
+
+	logger, buf := newTestLogger()
+	p := Provenance{}
+	d := &DefaultLoader{
+		Loader: TrackProvenance(p,
+			&EnvironmentLoader{},
+			&FlagLoader{Args: []string{"-token", "t0k3n"}},
+			&SecretLoader{},
+		),
+		Provenance: p,
+		Logger:     logger,
+	}
+
+	s := &SecretServer{}
+	require.NoError(t, d.Load(s))
+	require.Equal(t, "r3s0lv3d", s.Name)
+
+	out := buf.String()
+	require.Contains(t, out, `msg="environment variable found" env=SECRETSERVER_PASSWORD field=Password value=******`)
+	require.Contains(t, out, `msg="environment variable found" env=SECRETSERVER_NAME field=Name value=env://LOG_NAME`)
+	require.Contains(t, out, `msg="flag parsed" flag=-token field=Token value=******`)
+	require.Contains(t, out, `msg="secret resolved" field=Name scheme=env`)
+	require.Contains(t, out, `msg="field loaded" field=Name source="environment via secret resolver" value=******`)
+	require.Contains(t, out, `msg="field loaded" field=Password source=environment value=******`)
+
+	require.NotContains(t, out, "s3cr3t")
+	require.NotContains(t, out, "t0k3n")
+	require.NotContains(t, out, "r3s0lv3d")
+
+	// the loaders keep the logger, but their own one is not replaced
+	own, _ := newTestLogger()
+	env, flags := &EnvironmentLoader{}, &FlagLoader{Args: []string{}, Logger: own}
+	d = &DefaultLoader{Loader: MultiLoader(env, flags), Logger: logger}
+	require.NoError(t, d.Load(&SecretServer{}))
+	require.Same(t, logger, env.Logger)
+	require.Same(t, own, flags.Logger)
+}
+
+func TestLoggerFileVar(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cr3t\n"), 0o600))
+
+	type Config struct {
+		Name     string
+		Password string
+	}
+
+	t.Setenv("CONFIG_NAME", "koding")
+	t.Setenv("CONFIG_PASSWORD_FILE", secret)
+
+	logger, buf := newTestLogger()
+	p := Provenance{}
+	d := &DefaultLoader{
+		Loader:     TrackProvenance(p, &EnvironmentLoader{ResolveFiles: true}),
+		Provenance: p,
+		Logger:     logger,
+	}
+
+	c := &Config{}
+	require.NoError(t, d.Load(c))
+	require.Equal(t, "s3cr3t", c.Password)
+	require.Equal(t, Provenance{"Name": "environment", "Password": "environment CONFIG_PASSWORD_FILE"}, p)
+
+	out := buf.String()
+	require.Contains(t, out, `msg="environment variable found" env=CONFIG_NAME field=Name value=koding`)
+	require.Contains(t, out, `msg="environment variable found" env=CONFIG_PASSWORD_FILE field=Password value=******`)
+	require.Contains(t, out, `msg="field loaded" field=Password source="environment CONFIG_PASSWORD_FILE" value=******`)
+	require.NotContains(t, out, "s3cr3t")
+}
+
+func TestLoggerDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "name"), []byte("s3cr3t"), 0o600))
+
+	logger, buf := newTestLogger()
+	p := Provenance{}
+	d := &DefaultLoader{
+		Loader:     TrackProvenance(p, &DirectoryLoader{Path: dir}),
+		Provenance: p,
+		Logger:     logger,
+	}
+
+	s := &Server{}
+	require.NoError(t, d.Load(s))
+	require.Equal(t, "s3cr3t", s.Name)
+
+	out := buf.String()
+	require.Contains(t, out, `msg="directory file found" file=name field=Name value=******`)
+	require.Contains(t, out, `msg="field loaded" field=Name source="directory `+dir+`" value=******`)
+	require.NotContains(t, out, "s3cr3t")
+}
+
+func TestLoggerFile(t *testing.T) {
+	logger, buf := newTestLogger()
+
+	s := &Server{}
+	require.NoError(t, (&TOMLLoader{Path: testTOML, Logger: logger}).Load(s))
+
+	out := buf.String()
+	require.Contains(t, out, `msg="config file tried"`)
+	require.Contains(t, out, "found=true")
+	require.Contains(t, out, `msg="file value loaded" field=Name value=koding source="toml file `+testTOML+`"`)
+
+	buf.Reset()
+	err := (&TOMLLoader{Path: "missing.toml", Logger: logger}).Load(&Server{})
+	require.ErrorIs(t, err, ErrFileNotFound)
+	require.Contains(t, buf.String(), "found=false")
+}
+
+func TestLoggerDefaults(t *testing.T) {
+	logger, buf := newTestLogger()
+
+	s := &Server{}
+	require.NoError(t, (&TagLoader{Logger: logger}).Load(s))
+	require.Contains(t, buf.String(), `msg="default applied" field=Port value=6060`)
+}
+
+func TestLoggerInherited(t *testing.T) {
+	logger, buf := newTestLogger()
+
+	tag := &TagLoader{}
+	d := &DefaultLoader{Loader: MultiLoader(tag), Logger: logger}
+	require.NoError(t, d.Load(&Server{}))
+
+	require.Equal(t, logger, tag.Logger)
+	require.Contains(t, buf.String(), `msg="default applied"`)
+}
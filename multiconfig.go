@@ -1,8 +1,11 @@
 package multiconfig
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"reflect"
 	"strconv"
@@ -24,55 +27,147 @@ type Loader interface {
 // of load is TagLoader, FileLoader, EnvLoader and lastly FlagLoader. An error
 // in any step stops the loading process. Each step overrides the previous
 // step's config (i.e: defining a flag will override previous environment or
-// file config). To customize the order use the individual load functions.
+// file config). Secret references are left untouched, add a SecretLoader
+// after the other loaders to resolve them. To customize the order use the
+// individual load functions.
 type DefaultLoader struct {
 	Loader
 	Validator
+
+	// Provenance records which source set each field during the last load.
+	// It's only populated by the loaders created with New and NewWithPath.
+	Provenance Provenance
+
+	// OnDeprecated is called for every field tagged with
+	// `deprecated:"message"` which is set by a source. By default a warning
+	// is logged with Logger, or written to ErrorOutput if Logger is nil. Set
+	// it to a function doing nothing to silence the warnings.
+	OnDeprecated func(Deprecation)
+
+	// ExitCode is the status MustLoad and MustValidate exit with when the
+	// config cannot be loaded or validated. The default is 2.
+	ExitCode int
+
+	// ErrorOutput is where MustLoad and MustValidate write the error before
+	// exiting, and where the deprecated fields are reported without a
+	// Logger. By default os.Stderr is used.
+	ErrorOutput io.Writer
+
+	// Exit is called by MustLoad and MustValidate with the ExitCode, i.e: to
+	// run some cleanup or to panic instead. The default is os.Exit.
+	Exit func(code int)
+
+	// Logger logs the files which are tried, the values found by each
+	// loader and the source of each field at the debug level. Secrets are
+	// redacted. It's used by the built-in loaders which don't have a Logger
+	// of their own: Load sets their Logger field to it, so they keep using
+	// it if they are loaded on their own afterwards. Nothing is logged if
+	// it's nil.
+	Logger *slog.Logger
 }
 
-// NewWithPath returns a new instance of Loader to read from the given
-// configuration file.
-func NewWithPath(path string) *DefaultLoader {
-	loaders := []Loader{}
+// Load loads the source into the config defined by struct s and reports the
+// deprecated fields which are set.
+func (d *DefaultLoader) Load(s any) error {
+	return d.LoadContext(context.Background(), s)
+}
 
-	// Read default values defined via tag fields "default"
-	loaders = append(loaders, &TagLoader{})
+// LoadContext is like Load but stops as soon as ctx is done, i.e: to bound
+// the startup with a deadline. ctx is passed to the loaders which implement
+// ContextLoader.
+func (d *DefaultLoader) LoadContext(ctx context.Context, s any) error {
+	setLogger(d.Loader, d.Logger)
 
-	// Choose what while is passed
-	if strings.HasSuffix(path, "toml") {
-		loaders = append(loaders, &TOMLLoader{Path: path})
+	if err := loadContext(ctx, d.Loader, s); err != nil {
+		return err
+	}
+
+	logFields(d.Logger, s, d.Provenance)
+
+	onDeprecated := d.OnDeprecated
+	if onDeprecated == nil {
+		onDeprecated = d.warnDeprecated
 	}
 
-	if strings.HasSuffix(path, "json") {
-		loaders = append(loaders, &JSONLoader{Path: path})
+	for _, dep := range deprecations(s, d.Provenance) {
+		onDeprecated(dep)
 	}
 
-	if strings.HasSuffix(path, "yml") || strings.HasSuffix(path, "yaml") {
-		loaders = append(loaders, &YAMLLoader{Path: path})
+	return nil
+}
+
+// NewWithPath returns a new instance of Loader to read from the given
+// configuration file. The path can be an HTTP(S) URL, see URLLoader and
+// NewWithURL.
+func NewWithPath(path string) *DefaultLoader {
+	// Choose what while is passed
+	var file Loader
+	if isURL(path) {
+		file = &URLLoader{URL: path}
+	} else if format, ok := fileFormat(path); ok {
+		file = newFileLoader(format, path, nil, false)
+	}
+
+	return newWithFile(file)
+}
+
+// NewWithURL returns a new instance of Loader to read from the config file
+// fetched by u, i.e: to send headers or to trust an internal CA.
+func NewWithURL(u *URLLoader) *DefaultLoader {
+	return newWithFile(u)
+}
+
+// newWithFile returns a DefaultLoader reading from the given file loader, if
+// it's not nil.
+func newWithFile(file Loader) *DefaultLoader {
+	loaders := []Loader{}
+
+	// Read default values defined via tag fields "default"
+	loaders = append(loaders, &TagLoader{})
+	if file != nil {
+		loaders = append(loaders, file)
 	}
 
 	e := &EnvironmentLoader{}
 	f := &FlagLoader{}
 
 	loaders = append(loaders, e, f)
-	loader := MultiLoader(loaders...)
 
 	d := &DefaultLoader{}
-	d.Loader = loader
+	d.Provenance = Provenance{}
+	d.Loader = TrackProvenance(d.Provenance, loaders...)
+	d.Validator = MultiValidator(&RequiredValidator{})
+	return d
+}
+
+// NewWithProfile returns a new instance of Loader to read from the given
+// configuration file and the layers of a profile, see ProfileLoader. If
+// profile is empty, it's selected with the -profile flag or the
+// {STRUCTNAME}_PROFILE environment variable, so the config struct must not
+// have a Profile field: the ProfileLoader and the FlagLoader.ProfileFlag can
+// be given other names instead.
+func NewWithProfile(path, profile string) *DefaultLoader {
+	d := &DefaultLoader{}
+	d.Provenance = Provenance{}
+	d.Loader = TrackProvenance(d.Provenance,
+		&TagLoader{},
+		&ProfileLoader{Path: path, Profile: profile},
+		&EnvironmentLoader{},
+		&FlagLoader{ProfileFlag: "profile"},
+	)
 	d.Validator = MultiValidator(&RequiredValidator{})
 	return d
 }
 
 // New returns a new instance of DefaultLoader without any file loaders.
 func New() *DefaultLoader {
-	loader := MultiLoader(
+	d := &DefaultLoader{}
+	d.Provenance = Provenance{}
+	d.Loader = TrackProvenance(d.Provenance,
 		&TagLoader{},
 		&EnvironmentLoader{},
 		&FlagLoader{},
 	)
-
-	d := &DefaultLoader{}
-	d.Loader = loader
 	d.Validator = MultiValidator(&RequiredValidator{})
 	return d
 }
@@ -91,11 +186,13 @@ func MustLoad(conf any) {
 	d.MustLoad(conf)
 }
 
-// MustLoad is like Load but panics if the config cannot be parsed.
+// MustLoad is like Load but exits if the config cannot be parsed or
+// validated. The error is written to ErrorOutput and the program exits with
+// ExitCode, see Exit.
 func (d *DefaultLoader) MustLoad(conf any) {
 	if err := d.Load(conf); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(2)
+		d.exit(err)
+		return
 	}
 
 	// we at koding, believe having sane defaults in our system, this is the
@@ -106,15 +203,55 @@ func (d *DefaultLoader) MustLoad(conf any) {
 	}
 }
 
-// MustValidate validates the struct. It exits with status 1 if it can't
-// validate.
+// MustValidate validates the struct. It exits if it can't validate, see
+// MustLoad.
 func (d *DefaultLoader) MustValidate(conf any) {
 	if err := d.Validate(conf); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(2)
+		d.exit(err)
 	}
 }
 
+// warnDeprecated logs a warning for the deprecated field set by a source, or
+// writes it to ErrorOutput if there is no Logger.
+func (d *DefaultLoader) warnDeprecated(dep Deprecation) {
+	if d.Logger != nil {
+		d.Logger.Warn("deprecated field set",
+			"field", dep.Field,
+			"source", dep.Source,
+			"message", dep.Message,
+		)
+		return
+	}
+
+	w := d.ErrorOutput
+	if w == nil {
+		w = os.Stderr
+	}
+
+	fmt.Fprintf(w, "multiconfig: warning: %s\n", dep)
+}
+
+// exit writes err to ErrorOutput and calls Exit with ExitCode.
+func (d *DefaultLoader) exit(err error) {
+	w := d.ErrorOutput
+	if w == nil {
+		w = os.Stderr
+	}
+
+	code := d.ExitCode
+	if code == 0 {
+		code = 2
+	}
+
+	exit := d.Exit
+	if exit == nil {
+		exit = os.Exit
+	}
+
+	fmt.Fprintln(w, err)
+	exit(code)
+}
+
 // fieldSet sets field value from the given string value. It converts the
 // string value in a sane way and is useful for environment variables or flags
 // which are by nature in string types.
@@ -127,7 +264,7 @@ func fieldSet(field *structs.Field, v string) error {
 				typ = typ.Elem()
 			}
 
-			if err := field.Set(reflect.New(typ).Interface()); err != nil {
+			if err := setField(field, reflect.New(typ).Interface()); err != nil {
 				return err
 			}
 
@@ -137,6 +274,12 @@ func fieldSet(field *structs.Field, v string) error {
 		return f.Set(v)
 	}
 
+	// pointer fields stay nil until a source sets them, so "unset" can be
+	// told apart from the zero value
+	if field.Kind() == reflect.Pointer {
+		return fieldSetPointer(field, v)
+	}
+
 	// TODO: add support for other types
 	switch field.Kind() {
 	case reflect.Bool:
@@ -145,7 +288,7 @@ func fieldSet(field *structs.Field, v string) error {
 			return fmt.Errorf("cannot parse value '%s' of field '%s' as bool: %w", v, field.Name(), err)
 		}
 
-		if err := field.Set(val); err != nil {
+		if err := setField(field, val); err != nil {
 			return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 		}
 	case reflect.Int:
@@ -154,17 +297,17 @@ func fieldSet(field *structs.Field, v string) error {
 			return fmt.Errorf("cannot parse value '%s' of field '%s' as int: %w", v, field.Name(), err)
 		}
 
-		if err := field.Set(i); err != nil {
+		if err := setField(field, i); err != nil {
 			return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 		}
 	case reflect.String:
-		if err := field.Set(v); err != nil {
+		if err := setField(field, v); err != nil {
 			return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 		}
 	case reflect.Slice:
 		switch t := field.Value().(type) {
 		case []string:
-			if err := field.Set(strings.Split(v, ",")); err != nil {
+			if err := setField(field, strings.Split(v, ",")); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []int:
@@ -178,7 +321,7 @@ func fieldSet(field *structs.Field, v string) error {
 				list = append(list, int(i))
 			}
 
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []int64:
@@ -190,7 +333,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, i)
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []int32:
@@ -202,7 +345,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, int32(i))
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []int16:
@@ -214,7 +357,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, int16(i))
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []int8:
@@ -226,7 +369,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, int8(i))
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []uint:
@@ -238,7 +381,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, uint(i))
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []uint64:
@@ -250,7 +393,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, i)
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []uint32:
@@ -262,7 +405,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, uint32(i))
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []uint16:
@@ -274,7 +417,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, uint16(i))
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case []uint8:
@@ -286,7 +429,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				list = append(list, uint8(i))
 			}
-			if err := field.Set(list); err != nil {
+			if err := setField(field, list); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		default:
@@ -304,7 +447,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = val
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]int:
@@ -320,7 +463,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = int(i)
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]int64:
@@ -336,7 +479,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = i
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]int32:
@@ -352,7 +495,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = int32(i)
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]int16:
@@ -368,7 +511,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = int16(i)
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]int8:
@@ -384,7 +527,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = int8(i)
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]uint:
@@ -400,7 +543,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = uint(i)
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]uint64:
@@ -416,7 +559,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = i
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]uint32:
@@ -432,7 +575,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = uint32(i)
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]uint16:
@@ -448,7 +591,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = uint16(i)
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case map[string]uint8:
@@ -464,7 +607,7 @@ func fieldSet(field *structs.Field, v string) error {
 				}
 				output[key] = uint8(i)
 			}
-			if err := field.Set(output); err != nil {
+			if err := setField(field, output); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		default:
@@ -476,7 +619,7 @@ func fieldSet(field *structs.Field, v string) error {
 			return fmt.Errorf("cannot parse value '%s' of field '%s' as float64: %w", v, field.Name(), err)
 		}
 
-		if err := field.Set(f); err != nil {
+		if err := setField(field, f); err != nil {
 			return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 		}
 	case reflect.Int64:
@@ -487,7 +630,7 @@ func fieldSet(field *structs.Field, v string) error {
 				return fmt.Errorf("cannot parse value '%s' of field '%s' as duration: %w", v, field.Name(), err)
 			}
 
-			if err := field.Set(d); err != nil {
+			if err := setField(field, d); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		case int64:
@@ -496,7 +639,7 @@ func fieldSet(field *structs.Field, v string) error {
 				return fmt.Errorf("cannot parse value '%s' of field '%s' as int64: %w", v, field.Name(), err)
 			}
 
-			if err := field.Set(p); err != nil {
+			if err := setField(field, p); err != nil {
 				return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 			}
 		default:
@@ -509,7 +652,7 @@ func fieldSet(field *structs.Field, v string) error {
 			return fmt.Errorf("cannot parse value '%s' of field '%s' as uint: %w", v, field.Name(), err)
 		}
 
-		if err := field.Set(uint(u)); err != nil {
+		if err := setField(field, uint(u)); err != nil {
 			return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 		}
 	case reflect.Uint16:
@@ -518,7 +661,7 @@ func fieldSet(field *structs.Field, v string) error {
 			return fmt.Errorf("cannot parse value '%s' of field '%s' as uint16: %w", v, field.Name(), err)
 		}
 
-		if err := field.Set(uint16(u)); err != nil {
+		if err := setField(field, uint16(u)); err != nil {
 			return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 		}
 	case reflect.Uint32:
@@ -527,7 +670,7 @@ func fieldSet(field *structs.Field, v string) error {
 			return fmt.Errorf("cannot parse value '%s' of field '%s' as uint32: %w", v, field.Name(), err)
 		}
 
-		if err := field.Set(uint32(u)); err != nil {
+		if err := setField(field, uint32(u)); err != nil {
 			return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 		}
 	case reflect.Uint64:
@@ -536,7 +679,7 @@ func fieldSet(field *structs.Field, v string) error {
 			return fmt.Errorf("cannot parse value '%s' of field '%s' as uint64: %w", v, field.Name(), err)
 		}
 
-		if err := field.Set(u); err != nil {
+		if err := setField(field, u); err != nil {
 			return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
 		}
 	default:
@@ -545,3 +688,42 @@ func fieldSet(field *structs.Field, v string) error {
 
 	return nil
 }
+
+// fieldSetPointer sets the pointer field to a newly allocated value parsed
+// from v, the same way a field of the pointed type is.
+func fieldSetPointer(field *structs.Field, v string) error {
+	typ := reflect.TypeOf(field.Value()).Elem()
+
+	// a struct with a single field of the pointed type, named like the field
+	// so errors are the same
+	tmp := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: field.Name(),
+		Type: typ,
+	}}))
+
+	if err := fieldSet(structs.New(tmp.Interface()).Field(field.Name()), v); err != nil {
+		return err
+	}
+
+	if err := setField(field, tmp.Elem().Field(0).Addr().Interface()); err != nil {
+		return fmt.Errorf("failed to set parsed value of field '%s': %w", field.Name(), err)
+	}
+
+	return nil
+}
+
+// setField sets the field to val converted to the type of the field, so
+// fields of named types (i.e: type Level int) can be set too. structs panics
+// when the types don't match.
+func setField(field *structs.Field, val any) error {
+	v := reflect.ValueOf(val)
+	if t := reflect.TypeOf(field.Value()); v.Type() != t {
+		if !v.Type().ConvertibleTo(t) {
+			return fmt.Errorf("multiconfig: cannot set field '%s' of type %s to a value of type %s", field.Name(), t, v.Type())
+		}
+
+		v = v.Convert(t)
+	}
+
+	return field.Set(v.Interface())
+}
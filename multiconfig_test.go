@@ -1,6 +1,8 @@
 package multiconfig
 
 import (
+	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -275,3 +277,93 @@ func testNestedStruct(t *testing.T, s *NestedServer, d *NestedServer) {
 
 	testPostgres(t, s.DatabaseOptions.Postgres, d.DatabaseOptions.Postgres)
 }
+
+type PointerServer struct {
+	Name    *string
+	Port    *int  `default:"6060"`
+	Enabled *bool `default:"true"`
+	Timeout *time.Duration
+}
+
+func TestPointerFields(t *testing.T) {
+	s := &PointerServer{}
+	require.NoError(t, (&TagLoader{}).Load(s))
+	require.Nil(t, s.Name)
+	require.Nil(t, s.Timeout)
+	require.Equal(t, 6060, *s.Port)
+	require.True(t, *s.Enabled)
+
+	t.Setenv("POINTERSERVER_ENABLED", "false")
+	t.Setenv("POINTERSERVER_TIMEOUT", "5s")
+	require.NoError(t, (&EnvironmentLoader{}).Load(s))
+	require.False(t, *s.Enabled)
+	require.Equal(t, 5*time.Second, *s.Timeout)
+
+	f := &FlagLoader{Args: []string{"-name=", "-enabled"}}
+	require.NoError(t, f.Load(s))
+	require.Equal(t, "", *s.Name)
+	require.True(t, *s.Enabled)
+
+	err := (&FlagLoader{Args: []string{"-port", "http"}}).Load(&PointerServer{})
+	require.ErrorContains(t, err, "cannot parse value 'http' of field 'Port' as int")
+
+	s = &PointerServer{}
+	require.NoError(t, (&JSONLoader{Reader: strings.NewReader(`{"Port": 80}`)}).Load(s))
+	require.Equal(t, &PointerServer{Port: s.Port}, s)
+	require.Equal(t, 80, *s.Port)
+}
+
+func TestMustLoadExit(t *testing.T) {
+	var buf strings.Builder
+	var code int
+
+	d := &DefaultLoader{
+		Loader:      &FlagLoader{Args: []string{"-port", "http"}, Output: io.Discard},
+		Validator:   &RequiredValidator{},
+		ExitCode:    3,
+		ErrorOutput: &buf,
+		Exit:        func(c int) { code = c },
+	}
+
+	d.MustLoad(&Server{})
+	require.Equal(t, 3, code)
+	require.Contains(t, buf.String(), "multiconfig: flags -port: field 'Port': cannot parse value 'http'")
+
+	buf.Reset()
+	d.Loader = &FlagLoader{Args: []string{}}
+	d.ExitCode = 0
+
+	d.MustLoad(&Server{})
+	require.Equal(t, 2, code)
+	require.Equal(t, "multiconfig: field 'Name' is required\n", buf.String())
+}
+
+type Level int
+
+func TestNamedTypes(t *testing.T) {
+	type Server struct {
+		Level Level
+	}
+
+	t.Setenv("SERVER_LEVEL", "3")
+
+	s := &Server{}
+	require.NoError(t, (&EnvironmentLoader{}).Load(s))
+	require.Equal(t, Level(3), s.Level)
+}
+
+func TestNotStructPanics(t *testing.T) {
+	err := (&RequiredValidator{}).Validate(Server{Name: "koding", Postgres: Postgres{Port: 5432, Hosts: []string{"localhost"}}})
+	require.NoError(t, err)
+
+	err = (&RequiredValidator{}).Validate(Server{})
+	require.EqualError(t, err, "multiconfig: field 'Name' is required")
+
+	err = (&RequiredValidator{}).Validate(42)
+	require.ErrorIs(t, err, ErrNotStructPointer)
+
+	err = (&EnvironmentLoader{}).PrintEnvs(42)
+	require.ErrorIs(t, err, ErrNotStructPointer)
+
+	require.Empty(t, (&ProfileLoader{Args: []string{}}).ActiveProfile(nil))
+}
@@ -1,18 +1,26 @@
 package multiconfig
 
+import "context"
+
 type multiLoader []Loader
 
 // MultiLoader creates a loader that executes the loaders one by one in order
-// and returns on the first error.
+// and returns on the first error. Errors which are not a LoadError are
+// wrapped in one. The returned loader is a ContextLoader.
 func MultiLoader(loader ...Loader) Loader {
 	return multiLoader(loader)
 }
 
 // Load loads the source into the config defined by struct s
 func (m multiLoader) Load(s any) error {
+	return m.LoadContext(context.Background(), s)
+}
+
+// LoadContext is like Load but stops as soon as ctx is done.
+func (m multiLoader) LoadContext(ctx context.Context, s any) error {
 	for _, loader := range m {
-		if err := loader.Load(s); err != nil {
-			return err
+		if err := loadContext(ctx, loader, s); err != nil {
+			return wrapLoadError(loader, err)
 		}
 	}
 
@@ -0,0 +1,254 @@
+package multiconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/structs"
+)
+
+// ProfileLoader satisfies the loader interface. It loads a base config file
+// and then the layers of the selected profile on top of it:
+//
+//   - the overlay file next to the base file, i.e: config.production.toml
+//     for the profile "production" and the base file config.toml
+//   - the [profiles.production] section of the base file
+//
+// Both layers are optional, but the profile must exist in one of them. If no
+// profile is selected, only the base file is loaded. The "profiles" section
+// is skipped when the base file is loaded, so the config struct must not have
+// a field matching it.
+type ProfileLoader struct {
+	// Path is the path of the base file. Its extension defines the format
+	// of all the files.
+	Path string
+
+	// Profile is the name of the profile. If empty, it's read from the flag
+	// named by Flag, and then from the environment variable named by Env.
+	Profile string
+
+	// Flag is the name of the flag selecting the profile, without leading
+	// dashes. The default is "profile". The FlagLoader must accept it, see
+	// FlagLoader.ProfileFlag.
+	Flag string
+
+	// Env is the environment variable selecting the profile. The default is
+	// {STRUCTNAME}_PROFILE.
+	Env string
+
+	// Args defines a custom argument list to look for the flag. If nil,
+	// os.Args[1:] is used.
+	Args []string
+
+	// Strict makes keys which don't match any field an error, see
+	// UnknownKeysError.
+	Strict bool
+
+	// Logger logs the decisions of the loader and of its layers at the
+	// debug level. Nothing is logged if it's nil.
+	Logger *slog.Logger
+}
+
+// profileSection loads the section of a profile in a base file.
+type profileSection struct {
+	Loader
+	profile string
+	source  string
+}
+
+func (p *profileSection) String() string {
+	return fmt.Sprintf("profile %s of %s", p.profile, p.source)
+}
+
+// Load loads the source into the config defined by struct s
+func (p *ProfileLoader) Load(s any) error {
+	layers, err := p.layers(s)
+	if err != nil {
+		return err
+	}
+
+	return MultiLoader(layers...).Load(s)
+}
+
+// ActiveProfile returns the name of the profile selected for the config
+// struct s, or an empty string if none is.
+func (p *ProfileLoader) ActiveProfile(s any) string {
+	if p.Profile != "" {
+		return p.Profile
+	}
+
+	args := filterArgs(os.Args[1:])
+	if p.Args != nil {
+		args = p.Args
+	}
+
+	name := p.flag()
+
+	var profile string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || flagName != name {
+			continue
+		}
+
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+
+		// like the flag package, the last occurrence wins
+		profile = value
+	}
+
+	if profile != "" {
+		return profile
+	}
+
+	env := p.env(s)
+	if env == "" {
+		return ""
+	}
+
+	return os.Getenv(env)
+}
+
+// flag returns the name of the flag selecting the profile.
+func (p *ProfileLoader) flag() string {
+	if p.Flag == "" {
+		return "profile"
+	}
+
+	return p.Flag
+}
+
+// env returns the name of the environment variable selecting the profile
+// for the config struct s.
+func (p *ProfileLoader) env(s any) string {
+	if p.Env == "" && structs.IsStruct(s) {
+		return (&EnvironmentLoader{}).generateFieldName(structs.Name(s), "Profile")
+	}
+
+	return p.Env
+}
+
+// checkFields returns an ErrDuplicateFlag error if the flag or the
+// environment variable selecting the profile are also generated for a field
+// of s, whose value would be read as the name of the profile.
+func (p *ProfileLoader) checkFields(s any) error {
+	flag, env := p.flag(), p.env(s)
+	for _, field := range structs.Fields(s) {
+		if !field.IsExported() {
+			continue
+		}
+
+		if flagName(field.Name()) != flag && (&EnvironmentLoader{}).generateFieldName(structs.Name(s), field.Name()) != env {
+			continue
+		}
+
+		return &LoadError{
+			Loader: "profile",
+			Field:  field.Name(),
+			Err:    fmt.Errorf("%w: -%s and %s select the profile, set other names with Flag and Env", ErrDuplicateFlag, flag, env),
+		}
+	}
+
+	return nil
+}
+
+// layers returns the loaders of the base file and of the layers of the
+// active profile, in order.
+func (p *ProfileLoader) layers(s any) ([]Loader, error) {
+	if err := checkStructPointer(s); err != nil {
+		return nil, err
+	}
+
+	format, ok := fileFormat(p.Path)
+	if !ok {
+		return nil, &LoadError{Loader: "profile", Source: p.Path, Err: errors.New("unsupported config file")}
+	}
+
+	data, err := readConfig(p.Path, p.Logger)
+	if err != nil {
+		return nil, fileLoadError(format, p.Path, nil, err)
+	}
+
+	doc, err := decodeDocument(data, format)
+	if err != nil {
+		return nil, fileLoadError(format, p.Path, data, err)
+	}
+
+	// the sections are loaded from the original data, so that the errors
+	// point to the lines of the file
+	profiles, _ := doc["profiles"].(map[string]any)
+	base := newSectionLoader(format, p.Path, bytes.NewReader(data), p.Strict, fileSection{skip: "profiles"})
+	setLogger(base, p.Logger)
+	layers := []Loader{base}
+
+	if p.Profile == "" {
+		if err := p.checkFields(s); err != nil {
+			return nil, err
+		}
+	}
+
+	profile := p.ActiveProfile(s)
+	if profile == "" {
+		return layers, nil
+	}
+
+	if p.Logger != nil {
+		p.Logger.Debug("profile selected", "profile", profile)
+	}
+
+	found := false
+
+	ext := filepath.Ext(p.Path)
+	overlay := strings.TrimSuffix(p.Path, ext) + "." + profile + ext
+	if data, err := readConfig(overlay, p.Logger); err == nil {
+		found = true
+		layers = append(layers, newFileLoader(format, overlay, bytes.NewReader(data), p.Strict))
+	} else if !errors.Is(err, ErrFileNotFound) {
+		return nil, fileLoadError(format, overlay, nil, err)
+	}
+
+	if _, ok := profiles[profile].(map[string]any); ok {
+		found = true
+		section := fileSection{path: []string{"profiles", profile}}
+		layers = append(layers, &profileSection{
+			Loader:  newSectionLoader(format, p.Path, bytes.NewReader(data), p.Strict, section),
+			profile: profile,
+			source:  describeLoader(base),
+		})
+	}
+
+	if !found {
+		return nil, &LoadError{Loader: "profile", Source: profile, Err: errors.New("unknown profile")}
+	}
+
+	for _, layer := range layers {
+		setLogger(layer, p.Logger)
+	}
+
+	return layers, nil
+}
+
+// readConfig reads the config file at path, see getConfig.
+func readConfig(path string, logger *slog.Logger) ([]byte, error) {
+	file, err := getConfig(path, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
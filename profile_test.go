@@ -0,0 +1,230 @@
+package multiconfig
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ProfileServer struct {
+	Name  string `default:"koding"`
+	Port  int
+	Debug bool
+	Hosts []string
+}
+
+// writeProfiles writes a base config file with a "production" section and a
+// "staging" overlay file, and returns the path of the base file.
+func writeProfiles(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.toml": `
+Port = 6060
+Hosts = ["localhost"]
+
+[profiles.production]
+Debug = false
+Hosts = ["prod1", "prod2"]
+
+[profiles.staging]
+Port = 7070
+`,
+		"config.staging.toml": `
+Port = 8080
+Debug = true
+`,
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	return filepath.Join(dir, "config.toml")
+}
+
+func TestProfileLoader(t *testing.T) {
+	path := writeProfiles(t)
+
+	s := &ProfileServer{}
+	require.NoError(t, (&ProfileLoader{Path: path, Args: []string{}, Strict: true}).Load(s))
+	require.Equal(t, &ProfileServer{Port: 6060, Hosts: []string{"localhost"}}, s)
+
+	s = &ProfileServer{}
+	require.NoError(t, (&ProfileLoader{Path: path, Profile: "production", Strict: true}).Load(s))
+	require.Equal(t, &ProfileServer{Port: 6060, Hosts: []string{"prod1", "prod2"}}, s)
+
+	// the section is merged on top of the overlay file
+	s = &ProfileServer{}
+	require.NoError(t, (&ProfileLoader{Path: path, Profile: "staging", Strict: true}).Load(s))
+	require.Equal(t, &ProfileServer{Port: 7070, Debug: true, Hosts: []string{"localhost"}}, s)
+
+	err := (&ProfileLoader{Path: path, Profile: "testing"}).Load(&ProfileServer{})
+	require.EqualError(t, err, "multiconfig: profile testing: unknown profile")
+}
+
+func TestProfileLoaderActiveProfile(t *testing.T) {
+	s := &ProfileServer{}
+
+	p := &ProfileLoader{Args: []string{"-port", "80"}}
+	require.Empty(t, p.ActiveProfile(s))
+
+	t.Setenv("PROFILESERVER_PROFILE", "staging")
+	require.Equal(t, "staging", p.ActiveProfile(s))
+
+	p.Args = []string{"-profile", "production", "-port", "80"}
+	require.Equal(t, "production", p.ActiveProfile(s))
+
+	p.Args = []string{"--profile=testing"}
+	require.Equal(t, "testing", p.ActiveProfile(s))
+
+	p.Profile = "development"
+	require.Equal(t, "development", p.ActiveProfile(s))
+}
+
+func TestNewWithProfile(t *testing.T) {
+	path := writeProfiles(t)
+
+	t.Setenv("PROFILESERVER_PORT", "9090")
+
+	d := NewWithProfile(path, "staging")
+	s := &ProfileServer{}
+	require.NoError(t, d.Load(s))
+	require.Equal(t, &ProfileServer{Name: "koding", Port: 9090, Debug: true, Hosts: []string{"localhost"}}, s)
+
+	require.Equal(t, Provenance{
+		"Name":  "default tag",
+		"Port":  "environment",
+		"Debug": "toml file " + filepath.Join(filepath.Dir(path), "config.staging.toml"),
+		"Hosts": "toml file " + path,
+	}, d.Provenance)
+
+	d = NewWithProfile(path, "")
+	d.Loader.(*provenanceLoader).loaders[3].(*FlagLoader).Args = []string{"-profile", "production"}
+	d.Loader.(*provenanceLoader).loaders[1].(*ProfileLoader).Args = []string{"-profile", "production"}
+
+	s = &ProfileServer{}
+	require.NoError(t, d.Load(s))
+	require.Equal(t, []string{"prod1", "prod2"}, s.Hosts)
+	require.Equal(t, "profile production of toml file "+path, d.Provenance["Hosts"])
+}
+
+func TestProfileField(t *testing.T) {
+	path := writeProfiles(t)
+
+	type Config struct {
+		Profile string
+		Port    int
+	}
+
+	err := (&FlagLoader{ProfileFlag: "profile", Args: []string{}}).Load(&Config{})
+	require.ErrorIs(t, err, ErrDuplicateFlag)
+
+	err = NewWithProfile(path, "").Load(&Config{})
+	require.ErrorIs(t, err, ErrDuplicateFlag)
+	require.EqualError(t, err, "multiconfig: profile: field 'Profile': multiconfig: duplicate flag: -profile and CONFIG_PROFILE select the profile, set other names with Flag and Env")
+
+	t.Setenv("CONFIG_PROFILE", "dev")
+	t.Setenv("CONFIG_ENVIRONMENT", "staging")
+
+	d := &DefaultLoader{Loader: MultiLoader(
+		&ProfileLoader{Path: path, Flag: "environment", Env: "CONFIG_ENVIRONMENT", Args: []string{"-profile", "prod"}},
+		&EnvironmentLoader{},
+		&FlagLoader{ProfileFlag: "environment", Args: []string{"-profile", "prod"}},
+	)}
+
+	s := &Config{}
+	require.NoError(t, d.Load(s))
+	require.Equal(t, &Config{Profile: "prod", Port: 7070}, s)
+}
+
+func TestProfileFlagUsage(t *testing.T) {
+	var buf bytes.Buffer
+
+	f := &FlagLoader{ProfileFlag: "profile", Output: &buf, Args: []string{"-profile", "staging", "-port", "80"}}
+
+	s := &ProfileServer{}
+	require.NoError(t, f.Load(s))
+	require.Equal(t, 80, s.Port)
+
+	require.NoError(t, f.WriteUsage(&buf, s))
+	require.Contains(t, buf.String(), ":\n  -profile string\n        Name of the configuration profile.\n  -name string")
+}
+
+func TestProfileLoaderErrors(t *testing.T) {
+	files := map[string]string{
+		"config.toml": `
+Port = 6060
+
+[profiles.production]
+Debug = true
+Timeout = 5
+
+[profiles.staging]
+Port = "high"
+`,
+		"config.json": `{
+  "Port": 6060,
+  "profiles": {
+    "production": {
+      "Debug": true,
+      "Timeout": 5
+    },
+    "staging": {
+      "Port": "high"
+    }
+  }
+}`,
+		"config.yaml": `
+port: 6060
+profiles:
+  production:
+    debug: true
+    timeout: 5
+  staging:
+    port: high
+`,
+	}
+
+	wantLines := map[string][2]int{
+		"config.toml": {6, 9},
+		"config.json": {6, 9},
+		"config.yaml": {6, 8},
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		t.Run(name, func(t *testing.T) {
+			lines := wantLines[name]
+
+			err := (&ProfileLoader{Path: path, Profile: "production", Strict: true}).Load(&ProfileServer{})
+			var loadErr *LoadError
+			require.ErrorAs(t, err, &loadErr)
+			require.Equal(t, path, loadErr.Source)
+
+			// the YAML decoder reports the unknown keys itself
+			var unknown *UnknownKeysError
+			if errors.As(err, &unknown) {
+				require.Equal(t, []UnknownKey{{Key: "profiles.production.Timeout", Line: lines[0]}}, unknown.Keys)
+			} else {
+				require.Equal(t, lines[0], loadErr.Line)
+			}
+
+			err = (&ProfileLoader{Path: path, Profile: "staging"}).Load(&ProfileServer{})
+			require.ErrorAs(t, err, &loadErr)
+			require.Equal(t, path, loadErr.Source)
+			require.Equal(t, lines[1], loadErr.Line)
+			if name != "config.yaml" {
+				require.Equal(t, "Port", loadErr.Field)
+			}
+		})
+	}
+}
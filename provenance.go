@@ -0,0 +1,174 @@
+package multiconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Provenance records where the configuration values come from. It maps the
+// dotted path of the fields (e.g: "Postgres.Port") to a description of the
+// source which set their value last, such as "toml file config.toml",
+// "environment" or "environment APP_PASSWORD_FILE" for a value read from the
+// file named by the variable. Fields which were not set by any source are not
+// recorded.
+type Provenance map[string]string
+
+type provenanceLoader struct {
+	provenance Provenance
+	loaders    []Loader
+}
+
+// TrackProvenance creates a loader that executes the loaders one by one in
+// order, like MultiLoader, and records in p which one of them set each
+// field. p is reset on every load. The returned loader is a ContextLoader.
+func TrackProvenance(p Provenance, loader ...Loader) Loader {
+	return &provenanceLoader{
+		provenance: p,
+		loaders:    loader,
+	}
+}
+
+// Load loads the source into the config defined by struct s
+func (p *provenanceLoader) Load(s any) error {
+	return p.LoadContext(context.Background(), s)
+}
+
+// LoadContext is like Load but stops as soon as ctx is done.
+func (p *provenanceLoader) LoadContext(ctx context.Context, s any) error {
+	clear(p.provenance)
+
+	for _, loader := range p.loaders {
+		if err := p.load(ctx, s, loader); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// layeredLoader is implemented by the loaders which load several sources in
+// turn (i.e: ProfileLoader), so each of them is tracked separately.
+type layeredLoader interface {
+	layers(s any) ([]Loader, error)
+}
+
+func (p *provenanceLoader) load(ctx context.Context, s any, loader Loader) error {
+	if l, ok := loader.(layeredLoader); ok {
+		layers, err := l.layers(s)
+		if err != nil {
+			return wrapLoadError(loader, err)
+		}
+
+		for _, layer := range layers {
+			if err := p.load(ctx, s, layer); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	before := snapshot(s)
+
+	// the values read from {NAME}_FILE variables are recorded with the
+	// name of the variable
+	var fileVars map[string]string
+	err := ctx.Err()
+	if e, ok := loader.(*EnvironmentLoader); ok && err == nil {
+		fileVars, err = e.loadFileVars(s)
+	} else if err == nil {
+		err = loadContext(ctx, loader, s)
+	}
+
+	if err != nil {
+		return wrapLoadError(loader, err)
+	}
+
+	p.record(before, snapshot(s), loader, fileVars)
+	return nil
+}
+
+// MustLoad loads the source into the struct, it panics if gets any error
+func (p *provenanceLoader) MustLoad(s any) {
+	if err := p.Load(s); err != nil {
+		panic(err)
+	}
+}
+
+func (p *provenanceLoader) record(before, after map[string]any, loader Loader, fileVars map[string]string) {
+	source := describeLoader(loader)
+	_, resolver := loader.(*SecretLoader)
+
+	for name, val := range after {
+		if old, ok := before[name]; ok && reflect.DeepEqual(old, val) {
+			continue
+		}
+
+		// keep track of where the reference of a resolved secret is from
+		if prev, ok := p.provenance[name]; ok && resolver {
+			p.provenance[name] = prev + " via " + source
+			continue
+		}
+
+		if fileVar, ok := fileVars[name]; ok {
+			p.provenance[name] = source + " " + fileVar
+			continue
+		}
+
+		p.provenance[name] = source
+	}
+}
+
+// snapshot returns a copy of the leaf values of s keyed by their path.
+func snapshot(s any) map[string]any {
+	values := map[string]any{}
+	for _, field := range leafFields(configFields(reflect.ValueOf(s), nil)) {
+		values[field.Name()] = copyValue(field.Value).Interface()
+	}
+
+	return values
+}
+
+// describeLoader returns a short description of the source of a loader, used
+// as provenance.
+func describeLoader(l Loader) string {
+	switch l := l.(type) {
+	case fmt.Stringer:
+		return l.String()
+	case *TagLoader:
+		return "default tag"
+	case *TOMLLoader:
+		return describeFile("toml", l.Path)
+	case *JSONLoader:
+		return describeFile("json", l.Path)
+	case *YAMLLoader:
+		return describeFile("yaml", l.Path)
+	case *EnvironmentLoader:
+		return "environment"
+	case *FlagLoader:
+		return "flags"
+	case *InterfaceLoader:
+		return "ApplyDefaults"
+	case *SecretLoader:
+		return "secret resolver"
+	case *ProfileLoader:
+		return "profile"
+	case *KVLoader:
+		return "kv store"
+	case *URLLoader:
+		return "url " + l.URL
+	case *DirectoryLoader:
+		return "directory " + l.Path
+	default:
+		return fmt.Sprintf("%T", l)
+	}
+}
+
+func describeFile(format, path string) string {
+	if path == "" {
+		return format + " reader"
+	}
+
+	return format + " file " + path
+}
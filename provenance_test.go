@@ -0,0 +1,28 @@
+package multiconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackProvenance(t *testing.T) {
+	t.Setenv("SERVER_PORT", "4000")
+	t.Setenv("SERVER_POSTGRES_DBNAME", "env://OTHER_DBNAME")
+	t.Setenv("OTHER_DBNAME", "resolved")
+
+	p := Provenance{"Stale": "flags"}
+	l := TrackProvenance(p, &TagLoader{}, &TOMLLoader{Path: testTOML}, &EnvironmentLoader{}, &SecretLoader{})
+
+	s := &Server{}
+	require.NoError(t, l.Load(s))
+
+	require.Equal(t, "default tag", p["Epoch"])
+	require.Equal(t, "toml file "+testTOML, p["Name"])
+	require.Equal(t, "toml file "+testTOML, p["Postgres.Hosts"])
+	require.Equal(t, "environment", p["Port"])
+	require.Equal(t, "environment via secret resolver", p["Postgres.DBName"])
+	require.Equal(t, "resolved", s.Postgres.DBName)
+
+	require.NotContains(t, p, "Stale")
+}
@@ -0,0 +1,248 @@
+package multiconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SecretResolver resolves a secret reference into its value. References are
+// string values of the form "scheme://ref", the resolver is called with the
+// ref part only, i.e: "vault://secret/db#password" is resolved with
+// "secret/db#password". The "base64" scheme accepts the "base64:data" form
+// too. Other values, such as "file:test.db" or "env:prod", are not
+// references.
+type SecretResolver interface {
+	// Resolve returns the value referenced by ref
+	Resolve(ref string) (string, error)
+}
+
+// ContextSecretResolver is implemented by the secret resolvers which can be
+// cancelled, i.e: the ones calling a remote secret store. SecretLoader calls
+// ResolveContext instead of Resolve when it's loading with a context.
+type ContextSecretResolver interface {
+	SecretResolver
+
+	// ResolveContext is like Resolve but stops as soon as ctx is done
+	ResolveContext(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc is an adapter to allow the use of ordinary functions as
+// SecretResolver.
+type SecretResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref).
+func (f SecretResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]SecretResolver{
+		"file":   SecretResolverFunc(resolveFile),
+		"env":    SecretResolverFunc(resolveEnv),
+		"base64": SecretResolverFunc(resolveBase64),
+	}
+)
+
+// RegisterSecretResolver makes a SecretResolver available for the given
+// scheme to every SecretLoader. Registering a resolver for an already
+// registered scheme replaces it. The "file", "env" and "base64" schemes are
+// registered by default.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+
+	if r == nil {
+		delete(resolvers, scheme)
+		return
+	}
+
+	resolvers[scheme] = r
+}
+
+// SecretLoader satisfies the loader interface. It doesn't read any source on
+// its own but replaces the secret references set by the previous loaders
+// with their resolved value, so it should be the last one of a MultiLoader.
+// String fields, string slices and string maps of nested structs are
+// resolved, values which are not references or have an unknown scheme are
+// left untouched.
+//
+// The loaders returned by New, NewWithPath and NewWithProfile don't resolve
+// references, add a SecretLoader to your MultiLoader or use Load with
+// WithSecrets to resolve them.
+type SecretLoader struct {
+	// Resolvers holds resolvers specific to this loader. They take
+	// precedence over the ones registered with RegisterSecretResolver.
+	Resolvers map[string]SecretResolver
+
+	// Logger logs the references which are resolved at the debug level,
+	// never their value. Nothing is logged if it's nil.
+	Logger *slog.Logger
+}
+
+// Load resolves the secret references of the config defined by struct s
+func (l *SecretLoader) Load(s any) error {
+	return l.LoadContext(context.Background(), s)
+}
+
+// LoadContext is like Load but stops as soon as ctx is done. ctx is passed to
+// the resolvers which implement ContextSecretResolver.
+func (l *SecretLoader) LoadContext(ctx context.Context, s any) error {
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
+	return l.processValue(ctx, "", reflect.ValueOf(s).Elem())
+}
+
+// processValue walks v recursively and resolves every string it finds
+func (l *SecretLoader) processValue(ctx context.Context, fieldName string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+
+		return l.processValue(ctx, fieldName, v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Name
+			if fieldName != "" {
+				name = fieldName + "." + name
+			}
+
+			if err := l.processValue(ctx, name, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		val, err := l.resolve(ctx, fieldName, v.String())
+		if err != nil {
+			return err
+		}
+
+		if v.CanSet() {
+			v.SetString(val)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if err := l.processValue(ctx, fieldName, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			val, err := l.resolve(ctx, fieldName, iter.Value().String())
+			if err != nil {
+				return err
+			}
+
+			v.SetMapIndex(iter.Key(), reflect.ValueOf(val).Convert(v.Type().Elem()))
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the resolved value of s if it's a reference with a known
+// scheme, otherwise s is returned as is.
+func (l *SecretLoader) resolve(ctx context.Context, fieldName, s string) (string, error) {
+	scheme, ref, ok := strings.Cut(s, "://")
+	if !ok {
+		// only base64 has a bare form, so plain values such as the
+		// "file:test.db" DSN of SQLite are not mistaken for references
+		scheme, ref, ok = strings.Cut(s, ":")
+		if !ok || scheme != "base64" {
+			return s, nil
+		}
+	}
+
+	if scheme == "" {
+		return s, nil
+	}
+
+	r := l.resolver(scheme)
+	if r == nil {
+		return s, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	var val string
+	var err error
+	if cr, ok := r.(ContextSecretResolver); ok {
+		val, err = cr.ResolveContext(ctx, ref)
+	} else {
+		val, err = r.Resolve(ref)
+	}
+	if err != nil {
+		return "", &LoadError{Loader: "secret resolver", Source: scheme, Field: fieldName, Err: err}
+	}
+
+	if l.Logger != nil {
+		l.Logger.Debug("secret resolved", "field", fieldName, "scheme", scheme)
+	}
+
+	return val, nil
+}
+
+func (l *SecretLoader) resolver(scheme string) SecretResolver {
+	if r, ok := l.Resolvers[scheme]; ok {
+		return r
+	}
+
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+
+	return resolvers[scheme]
+}
+
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func resolveEnv(name string) (string, error) {
+	val, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return val, nil
+}
+
+func resolveBase64(data string) (string, error) {
+	val, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+
+	return string(val), nil
+}
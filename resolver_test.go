@@ -0,0 +1,99 @@
+package multiconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type SecretsConfig struct {
+	Name     string
+	Password string
+	Token    string
+	Key      string
+	Hosts    []string
+	Labels   map[string]string
+	Vault    struct {
+		Password string
+	}
+}
+
+func TestSecretLoader(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cr3t\n"), 0o600))
+
+	t.Setenv("OTHER_TOKEN", "t0k3n")
+
+	vault := SecretResolverFunc(func(ref string) (string, error) {
+		path, key, _ := strings.Cut(ref, "#")
+		if path != "secret/db" {
+			return "", errors.New("not found")
+		}
+		return "vault-" + key, nil
+	})
+
+	s := &SecretsConfig{
+		Name:     "koding",
+		Password: "file://" + secret,
+		Token:    "env://OTHER_TOKEN",
+		Key:      "base64:a29kaW5n",
+		Hosts:    []string{"postgres://localhost", "env://OTHER_TOKEN"},
+		Labels:   map[string]string{"token": "env://OTHER_TOKEN"},
+	}
+	s.Vault.Password = "vault://secret/db#password"
+
+	l := &SecretLoader{Resolvers: map[string]SecretResolver{"vault": vault}}
+	require.NoError(t, l.Load(s))
+
+	require.Equal(t, "koding", s.Name)
+	require.Equal(t, "s3cr3t", s.Password)
+	require.Equal(t, "t0k3n", s.Token)
+	require.Equal(t, "koding", s.Key)
+	require.Equal(t, []string{"postgres://localhost", "t0k3n"}, s.Hosts)
+	require.Equal(t, map[string]string{"token": "t0k3n"}, s.Labels)
+	require.Equal(t, "vault-password", s.Vault.Password)
+
+	s.Vault.Password = "vault://secret/missing#password"
+	err := l.Load(s)
+	require.EqualError(t, err, "multiconfig: secret resolver vault: field 'Vault.Password': not found")
+}
+
+func TestSecretLoaderPlainValues(t *testing.T) {
+	type DSNConfig struct {
+		DSN   string `default:"file:test.db?cache=shared"`
+		Env   string `default:"env:prod"`
+		Label string `default:"test:koding"`
+	}
+
+	RegisterSecretResolver("test", SecretResolverFunc(func(ref string) (string, error) {
+		return strings.ToUpper(ref), nil
+	}))
+	defer RegisterSecretResolver("test", nil)
+
+	s := &DSNConfig{}
+	require.NoError(t, New().Load(s))
+	require.Equal(t, &DSNConfig{DSN: "file:test.db?cache=shared", Env: "env:prod", Label: "test:koding"}, s)
+}
+
+func TestSecretLoaderRegistry(t *testing.T) {
+	RegisterSecretResolver("test", SecretResolverFunc(func(ref string) (string, error) {
+		return strings.ToUpper(ref), nil
+	}))
+	defer RegisterSecretResolver("test", nil)
+
+	t.Setenv("SECRETSCONFIG_PASSWORD", "test://koding")
+
+	// the references are only resolved on demand
+	s := &SecretsConfig{}
+	require.NoError(t, New().Load(s))
+	require.Equal(t, "test://koding", s.Password)
+
+	s, err := Load[SecretsConfig](WithSecrets(), WithArgs([]string{}))
+	require.NoError(t, err)
+	require.Equal(t, "KODING", s.Password)
+}
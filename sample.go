@@ -0,0 +1,53 @@
+package multiconfig
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Sample writes a sample configuration file of the struct s to w in the given
+// format with the default Dumper settings.
+func Sample(s any, format Format, w io.Writer) error {
+	return (&Dumper{}).Sample(s, format, w)
+}
+
+// Sample writes a sample configuration file of the struct s to w in the given
+// format. Only the type of s is used: every field is written with the value
+// of its "default" tag, or its zero value, and is documented with a comment
+// made of its "flagUsage" tag and whether it's required. Comments are not
+// supported by FormatJSON.
+func (d *Dumper) Sample(s any, format Format, w io.Writer) error {
+	t := reflect.TypeOf(s)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("multiconfig: cannot generate a sample of %T: not a struct", s)
+	}
+
+	v := reflect.New(t)
+	if err := (&TagLoader{}).Load(v.Interface()); err != nil {
+		return err
+	}
+
+	sample := *d
+	sample.sample = true
+	return sample.Dump(v.Interface(), format, w)
+}
+
+// sampleComments documents the field in a sample configuration file.
+func sampleComments(field *configField) []string {
+	comments := []string{}
+	if usage := field.Field.Tag.Get("flagUsage"); usage != "" {
+		comments = append(comments, strings.Split(usage, "\n")...)
+	}
+
+	if field.Field.Tag.Get("required") == "true" {
+		comments = append(comments, "required")
+	}
+
+	return comments
+}
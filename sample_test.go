@@ -0,0 +1,73 @@
+package multiconfig
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type SampleServer struct {
+	Name     string `required:"true" flagUsage:"Name of the server"`
+	Port     int    `default:"6060" flagUsage:"Port to listen on"`
+	Users    []string
+	Postgres struct {
+		Hosts  []string `required:"true"`
+		DBName string   `default:"configdb"`
+	} `flagUsage:"Postgres connection"`
+}
+
+func TestSampleTOML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Sample(&SampleServer{Name: "ignored"}, FormatTOML, &buf))
+
+	expected := `# Name of the server
+# required
+Name = ""
+# Port to listen on
+Port = 6060
+Users = []
+
+# Postgres connection
+[Postgres]
+# required
+Hosts = []
+DBName = "configdb"
+`
+	require.Equal(t, expected, buf.String())
+
+	s := &SampleServer{}
+	require.NoError(t, (&TOMLLoader{Reader: &buf}).Load(s))
+	require.Equal(t, 6060, s.Port)
+	require.Equal(t, "configdb", s.Postgres.DBName)
+}
+
+func TestSampleYAML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Sample(SampleServer{}, FormatYAML, &buf))
+
+	expected := `# Name of the server
+# required
+name: ""
+# Port to listen on
+port: 6060
+users: []
+# Postgres connection
+postgres:
+  # required
+  hosts: []
+  dbname: configdb
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestSampleJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Sample(&SampleServer{}, FormatJSON, &buf))
+
+	s := &SampleServer{}
+	require.NoError(t, (&JSONLoader{Reader: &buf}).Load(s))
+	require.Equal(t, 6060, s.Port)
+
+	require.Error(t, Sample("koding", FormatJSON, &buf))
+}
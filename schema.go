@@ -0,0 +1,298 @@
+package multiconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaDraft is the JSON Schema dialect generated by JSONSchema.
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the config
+// files of the given format for the struct s, i.e: for editor validation and
+// autocompletion. Properties are named the way the file loaders of the format
+// name them. Besides the types of the fields, the following tags are
+// reflected in the schema:
+//
+//	default:"6060"         default
+//	required:"true"        required
+//	flagUsage:"..."        description
+//	oneof:"debug info"     enum
+//	min:"1" max:"65535"    minimum/maximum, minLength/maxLength or minItems/maxItems
+//	pattern:"^[a-z]+$"     pattern
+//
+// The "required" tag is enforced by the RequiredValidator, used by the
+// DefaultLoader returned by New, and the "oneof", "min", "max" and "pattern"
+// tags by the ConstraintValidator.
+func JSONSchema(s any, format Format) ([]byte, error) {
+	t := reflect.TypeOf(s)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("multiconfig: cannot generate a schema of %T: not a struct", s)
+	}
+
+	switch format {
+	case FormatTOML, FormatJSON, FormatYAML:
+	default:
+		return nil, fmt.Errorf("multiconfig: unsupported format %q", format)
+	}
+
+	// the default values are loaded the same way TagLoader does
+	v := reflect.New(t)
+	if err := (&TagLoader{}).Load(v.Interface()); err != nil {
+		return nil, err
+	}
+
+	schema, err := objectSchema(configFields(v, nil), format)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.entries = append([]*dumpEntry{
+		{key: "$schema", value: schemaDraft},
+		{key: "title", value: t.Name()},
+	}, schema.entries...)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(schema); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// set adds or replaces the entry of the given key.
+func (t *dumpTable) set(key string, value any) {
+	for _, entry := range t.entries {
+		if entry.key == key {
+			entry.value = value
+			return
+		}
+	}
+
+	t.entries = append(t.entries, &dumpEntry{key: key, value: value})
+}
+
+// get returns the value of the entry of the given key, or nil.
+func (t *dumpTable) get(key string) any {
+	for _, entry := range t.entries {
+		if entry.key == key {
+			return entry.value
+		}
+	}
+
+	return nil
+}
+
+func objectSchema(fields []*configField, format Format) (*dumpTable, error) {
+	properties := &dumpTable{}
+	required := []any{}
+
+	for _, field := range fields {
+		key, ok := fieldKey(field.Field, format)
+		if !ok {
+			continue
+		}
+
+		schema, err := fieldSchema(field, format)
+		if err != nil {
+			return nil, fmt.Errorf("multiconfig: field '%s': %w", field.Name(), err)
+		}
+
+		properties.set(key, schema)
+
+		if field.Field.Tag.Get("required") == "true" {
+			required = append(required, key)
+		}
+	}
+
+	schema := &dumpTable{}
+	schema.set("type", "object")
+	schema.set("properties", properties)
+	if len(required) > 0 {
+		schema.set("required", required)
+	}
+
+	return schema, nil
+}
+
+func fieldSchema(field *configField, format Format) (*dumpTable, error) {
+	var schema *dumpTable
+	if field.IsNested() {
+		var err error
+		if schema, err = objectSchema(field.Fields, format); err != nil {
+			return nil, err
+		}
+	} else {
+		schema = typeSchema(field.Field.Type, format)
+	}
+
+	tag := field.Field.Tag
+	if usage := tag.Get("flagUsage"); usage != "" {
+		schema.set("description", usage)
+	}
+
+	secret := isSecret(field.Field)
+	if secret {
+		schema.set("writeOnly", true)
+	}
+
+	if tag.Get("default") != "" && !secret {
+		if val, ok := dumpValue(field.Value, format, false); ok {
+			schema.set("default", val)
+		}
+	}
+
+	if err := constraintsSchema(schema, field.Field); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// typeSchema returns the schema of the values of type t as read by the file
+// loaders of the given format.
+func typeSchema(t reflect.Type, format Format) *dumpTable {
+	schema := &dumpTable{}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		// JSONLoader only reads durations as nanoseconds
+		if format == FormatJSON {
+			schema.set("type", "integer")
+		} else {
+			schema.set("type", "string")
+		}
+		return schema
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		schema.set("type", "string")
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		schema.set("type", "boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema.set("type", "integer")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.set("type", "integer")
+		schema.set("minimum", 0)
+	case reflect.Float32, reflect.Float64:
+		schema.set("type", "number")
+	case reflect.String:
+		schema.set("type", "string")
+	case reflect.Slice, reflect.Array:
+		schema.set("type", "array")
+		schema.set("items", typeSchema(t.Elem(), format))
+	case reflect.Map:
+		schema.set("type", "object")
+		schema.set("additionalProperties", typeSchema(t.Elem(), format))
+	case reflect.Struct:
+		// the schema of the properties doesn't depend on their values
+		props, _ := objectSchema(configFields(reflect.New(t), nil), format)
+		return props
+	}
+
+	return schema
+}
+
+// constraintsSchema adds the constraints defined by the tags of the field to
+// its schema.
+func constraintsSchema(schema *dumpTable, sf reflect.StructField) error {
+	t := sf.Type
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// the oneof and pattern tags of a slice constrain its items, like the
+	// ConstraintValidator does
+	items, elem := schema, t
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if table, ok := schema.get("items").(*dumpTable); ok {
+			items, elem = table, t.Elem()
+			for elem.Kind() == reflect.Pointer {
+				elem = elem.Elem()
+			}
+		}
+	}
+
+	if oneof := sf.Tag.Get("oneof"); oneof != "" {
+		enum := []any{}
+		for _, item := range strings.Fields(oneof) {
+			val, err := schemaValue(elem, item)
+			if err != nil {
+				return err
+			}
+
+			enum = append(enum, val)
+		}
+
+		items.set("enum", enum)
+	}
+
+	minKey, maxKey := "minimum", "maximum"
+	switch t.Kind() {
+	case reflect.String:
+		minKey, maxKey = "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		minKey, maxKey = "minItems", "maxItems"
+	case reflect.Map:
+		minKey, maxKey = "minProperties", "maxProperties"
+	}
+
+	for _, c := range [][2]string{{minKey, "min"}, {maxKey, "max"}} {
+		key, tag := c[0], c[1]
+		val := sf.Tag.Get(tag)
+		if val == "" {
+			continue
+		}
+
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s tag '%s': %w", tag, val, err)
+		}
+
+		schema.set(key, f)
+	}
+
+	if pattern := sf.Tag.Get("pattern"); pattern != "" {
+		items.set("pattern", pattern)
+	}
+
+	return nil
+}
+
+// schemaValue parses the string s as a JSON value of the type t.
+func schemaValue(t reflect.Type, s string) (any, error) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if t == durationType {
+			return s, nil
+		}
+
+		return strconv.ParseFloat(s, 64)
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	default:
+		return s, nil
+	}
+}
@@ -0,0 +1,96 @@
+package multiconfig
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type SchemaServer struct {
+	Name     string `required:"true" flagUsage:"Name of the server" pattern:"^[a-z]+$"`
+	Port     int    `default:"6060" min:"1" max:"65535"`
+	Level    string `default:"info" oneof:"debug info error"`
+	Password Secret `default:"changeme"`
+	Timeout  time.Duration
+	Labels   map[string]string
+	Postgres struct {
+		Hosts  []string `required:"true" min:"1"`
+		DBName string   `default:"configdb"`
+		Port   *uint16
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema(&SchemaServer{}, FormatJSON)
+	require.NoError(t, err)
+
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "SchemaServer",
+  "type": "object",
+  "properties": {
+    "Name": {"type": "string", "description": "Name of the server", "pattern": "^[a-z]+$"},
+    "Port": {"type": "integer", "default": 6060, "minimum": 1, "maximum": 65535},
+    "Level": {"type": "string", "default": "info", "enum": ["debug", "info", "error"]},
+    "Password": {"type": "string", "writeOnly": true},
+    "Timeout": {"type": "integer"},
+    "Labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "Postgres": {
+      "type": "object",
+      "properties": {
+        "Hosts": {"type": "array", "items": {"type": "string"}, "minItems": 1},
+        "DBName": {"type": "string", "default": "configdb"},
+        "Port": {"type": "integer", "minimum": 0}
+      },
+      "required": ["Hosts"]
+    }
+  },
+  "required": ["Name"]
+}`
+	require.JSONEq(t, expected, string(data))
+}
+
+func TestJSONSchemaSlices(t *testing.T) {
+	type Config struct {
+		Levels []string `oneof:"debug info" min:"1"`
+		Ports  []*int   `oneof:"80 443"`
+		Tags   []string `pattern:"^[a-z]+$"`
+	}
+
+	data, err := JSONSchema(&Config{}, FormatJSON)
+	require.NoError(t, err)
+
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Config",
+  "type": "object",
+  "properties": {
+    "Levels": {"type": "array", "items": {"type": "string", "enum": ["debug", "info"]}, "minItems": 1},
+    "Ports": {"type": "array", "items": {"type": "integer", "enum": [80, 443]}},
+    "Tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}}
+  }
+}`
+	require.JSONEq(t, expected, string(data))
+}
+
+func TestJSONSchemaYAML(t *testing.T) {
+	data, err := JSONSchema(SchemaServer{}, FormatYAML)
+	require.NoError(t, err)
+
+	var schema struct {
+		Properties map[string]struct {
+			Type string
+		}
+		Required []string
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	require.Equal(t, "string", schema.Properties["timeout"].Type)
+	require.Equal(t, "object", schema.Properties["postgres"].Type)
+	require.Equal(t, []string{"name"}, schema.Required)
+
+	_, err = JSONSchema(SchemaServer{}, FormatEnv)
+	require.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package multiconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/fatih/structs"
+)
+
+// redacted is printed in place of the value of a secret.
+const redacted = "******"
+
+// Secret is a string holding sensitive data, such as a password or a token.
+// It can be loaded from every source like a plain string, but prints as
+// "******" with the fmt package, when marshaled to JSON, YAML or TOML and in
+// the usage output of the FlagLoader. Use Value to get the actual secret.
+//
+// Plain string fields can be redacted in the usage output and in dumps too
+// with the "secret" tag:
+//
+//	Password string `secret:"true"`
+type Secret string
+
+// Value returns the actual value of the secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns "******", or an empty string if the secret is not set.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+// GoString satisfies the fmt.GoStringer interface so %#v doesn't leak the
+// secret either.
+func (s Secret) GoString() string {
+	return fmt.Sprintf("multiconfig.Secret(%q)", s.String())
+}
+
+// MarshalText satisfies the encoding.TextMarshaler interface.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalYAML satisfies the yaml.Marshaler interface.
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// isSecret reports whether the field holds a secret which must not be
+// printed.
+func isSecret(sf reflect.StructField) bool {
+	return isSecretType(sf.Type) || sf.Tag.Get("secret") == "true"
+}
+
+// isSecretField is like isSecret for a structs.Field.
+func isSecretField(field *structs.Field) bool {
+	return isSecretType(reflect.TypeOf(field.Value())) || field.Tag("secret") == "true"
+}
+
+// isSecretType reports whether the values of type t hold a Secret, i.e: a
+// *Secret, a []Secret or a map[string]Secret.
+func isSecretType(t reflect.Type) bool {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t == secretType
+		}
+	}
+
+	return false
+}
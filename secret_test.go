@@ -0,0 +1,97 @@
+package multiconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type SecretServer struct {
+	Name     string
+	Password Secret
+	Token    string `secret:"true"`
+}
+
+func TestSecret(t *testing.T) {
+	s := Secret("s3cr3t")
+
+	require.Equal(t, "s3cr3t", s.Value())
+	require.Equal(t, "******", fmt.Sprintf("%v", s))
+	require.Equal(t, "******", fmt.Sprintf("%s", s))
+	require.Equal(t, `multiconfig.Secret("******")`, fmt.Sprintf("%#v", s))
+	require.Equal(t, "", Secret("").String())
+
+	c := &SecretServer{Name: "koding", Password: "s3cr3t"}
+
+	data, err := json.Marshal(c)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Name":"koding","Password":"******","Token":""}`, string(data))
+
+	data, err = yaml.Marshal(c)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "password: '******'")
+	require.NotContains(t, string(data), "s3cr3t")
+
+	var buf bytes.Buffer
+	require.NoError(t, toml.NewEncoder(&buf).Encode(c))
+	require.NotContains(t, buf.String(), "s3cr3t")
+}
+
+func TestSecretLoad(t *testing.T) {
+	t.Setenv("SECRETSERVER_PASSWORD", "s3cr3t")
+
+	c := &SecretServer{}
+	require.NoError(t, (&EnvironmentLoader{}).Load(c))
+	require.Equal(t, Secret("s3cr3t"), c.Password)
+
+	c = &SecretServer{}
+	require.NoError(t, (&JSONLoader{Reader: bytes.NewBufferString(`{"Password":"s3cr3t"}`)}).Load(c))
+	require.Equal(t, Secret("s3cr3t"), c.Password)
+}
+
+func TestSecretFlagUsage(t *testing.T) {
+	c := &SecretServer{Name: "koding", Password: "s3cr3t", Token: "t0k3n"}
+
+	m := &FlagLoader{Args: []string{}}
+	require.NoError(t, m.Load(c))
+
+	var buf bytes.Buffer
+	m.flagSet.SetOutput(&buf)
+	m.flagSet.VisitAll(func(f *flag.Flag) {
+		require.NotContains(t, f.DefValue, "s3cr3t")
+		require.NotContains(t, f.DefValue, "t0k3n")
+	})
+	m.flagSet.PrintDefaults()
+
+	require.Contains(t, buf.String(), "koding")
+	require.NotContains(t, buf.String(), "s3cr3t")
+	require.NotContains(t, buf.String(), "t0k3n")
+}
+
+func TestSecretTypes(t *testing.T) {
+	type Config struct {
+		Password *Secret
+		Keys     []Secret
+		Tokens   map[string]Secret
+		Name     *string
+	}
+
+	typ := reflect.TypeOf(Config{})
+	for i, want := range []bool{true, true, true, false} {
+		require.Equal(t, want, isSecret(typ.Field(i)), typ.Field(i).Name)
+	}
+
+	t.Setenv("CONFIG_PASSWORD", "s3cr3t")
+
+	logger, buf := newTestLogger()
+	require.NoError(t, (&EnvironmentLoader{Logger: logger}).Load(&Config{}))
+	require.Contains(t, buf.String(), "field=Password value=******")
+	require.NotContains(t, buf.String(), "s3cr3t")
+}
@@ -0,0 +1,178 @@
+package multiconfig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// setting describes how a field of a config struct is set by each of the
+// built-in sources. It's used to document the configuration.
+type setting struct {
+	field *configField
+
+	// Flag is the name of the flag generated by the FlagLoader, without
+	// leading dashes
+	Flag string
+
+	// Env is the environment variable read by the EnvironmentLoader
+	Env string
+
+	// Keys holds the dotted key of the field in TOML and JSON files, by
+	// format. Formats excluding the field or one of its parents with a "-"
+	// tag have no key.
+	Keys map[Format]string
+
+	Type     string
+	Default  string
+	Required bool
+	Usage    string
+
+	// Hidden is true if the field or one of its parents is tagged with
+	// `hidden:"true"`. Hidden fields still work but are not documented.
+	Hidden bool
+
+	// Deprecated holds the message of the "deprecated" tag of the field
+	Deprecated string
+}
+
+// Name returns the dotted path of the field, i.e: "Postgres.Port".
+func (s *setting) Name() string {
+	return s.field.Name()
+}
+
+// keyFormats are the formats of the keys of a setting.
+var keyFormats = []Format{FormatTOML, FormatJSON}
+
+// Key returns the keys of the field in the config files, followed by their
+// format if they differ, i.e: "Postgres.db_name (toml), Postgres.DBName
+// (json)".
+func (s *setting) Key() string {
+	if len(s.Keys) == len(keyFormats) && s.Keys[FormatTOML] == s.Keys[FormatJSON] {
+		return s.Keys[FormatTOML]
+	}
+
+	var keys []string
+	for _, format := range keyFormats {
+		if key, ok := s.Keys[format]; ok {
+			keys = append(keys, fmt.Sprintf("%s (%s)", key, format))
+		}
+	}
+
+	return strings.Join(keys, ", ")
+}
+
+// Group returns the dotted path of the nested struct the field belongs to,
+// or an empty string for the fields of the root struct.
+func (s *setting) Group() string {
+	return strings.Join(s.field.Path[:len(s.field.Path)-1], ".")
+}
+
+// settings returns the settings of the config struct s, in the order of the
+// fields. The names of the flags and the environment variables are generated
+// by the given loaders, so they must be configured like the ones used to
+// load s.
+func settings(s any, f *FlagLoader, e *EnvironmentLoader) ([]*setting, error) {
+	if err := checkStructPointer(s); err != nil {
+		return nil, err
+	}
+
+	// work on a copy to not change the flag set of the caller
+	fl := *f
+	if err := fl.defineFlags(s); err != nil {
+		return nil, err
+	}
+
+	flags := map[string]*flag.Flag{}
+	fl.flagSet.VisitAll(func(f *flag.Flag) {
+		if v, ok := f.Value.(*fieldValue); ok {
+			flags[strings.Join(v.path, ".")] = f
+		}
+	})
+
+	envs := map[string]string{}
+	e.visitEnvs(s, func(path []string, name string, _ bool) {
+		envs[strings.Join(path, ".")] = name
+	})
+
+	var list []*setting
+	var walk func(fields []*configField, parents map[Format][]string, hidden bool)
+	walk = func(fields []*configField, parents map[Format][]string, hidden bool) {
+		for _, field := range fields {
+			keys := map[Format][]string{}
+			for format, path := range parents {
+				if key, ok := fieldKey(field.Field, format); ok {
+					keys[format] = append(path[:len(path):len(path)], key)
+				}
+			}
+			hidden := hidden || field.Field.Tag.Get("hidden") == "true"
+
+			if field.IsNested() {
+				walk(field.Fields, keys, hidden)
+				continue
+			}
+
+			st := &setting{
+				field:      field,
+				Env:        envs[field.Name()],
+				Keys:       map[Format]string{},
+				Type:       typeName(field.Field.Type),
+				Default:    field.Field.Tag.Get("default"),
+				Required:   field.Field.Tag.Get("required") == "true",
+				Usage:      field.Field.Tag.Get("flagUsage"),
+				Hidden:     hidden,
+				Deprecated: field.Field.Tag.Get("deprecated"),
+			}
+
+			for format, key := range keys {
+				st.Keys[format] = strings.Join(key, ".")
+			}
+
+			if fl, ok := flags[field.Name()]; ok {
+				st.Flag = fl.Name
+				st.Usage = fl.Usage
+			}
+
+			if st.Default != "" && isSecret(field.Field) {
+				st.Default = redacted
+			}
+
+			list = append(list, st)
+		}
+	}
+	root := map[Format][]string{}
+	for _, format := range keyFormats {
+		root[format] = nil
+	}
+	walk(configFields(reflect.ValueOf(s), nil), root, false)
+
+	return list, nil
+}
+
+// typeName returns a short, human readable name of the type t.
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case durationType:
+		return "duration"
+	case secretType:
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "[]" + typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	}
+
+	if t.Name() != "" && t.PkgPath() == "" {
+		return t.Name()
+	}
+
+	return t.String()
+}
@@ -0,0 +1,272 @@
+package multiconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds a config of type T which can be reloaded at runtime, i.e: on
+// SIGHUP. The config is an immutable snapshot: Get can be called from any
+// goroutine, and every reload stores a new config instead of modifying the
+// current one.
+type Store[T any] struct {
+	loader  *DefaultLoader
+	current atomic.Pointer[T]
+
+	// reloadMu serializes the reloads, so the loaders and their Provenance
+	// are never used concurrently.
+	reloadMu sync.Mutex
+
+	mu          sync.Mutex
+	nextID      int
+	subscribers []subscriber[T]
+}
+
+type subscriber[T any] struct {
+	id int
+
+	// path is the field path the subscriber is interested in, or empty for
+	// all the reloads
+	path string
+	fn   func(old, new *T, changes []Change)
+}
+
+// NewStore loads and validates a config of type T, which must be a struct,
+// with loader and returns a Store holding it.
+func NewStore[T any](loader *DefaultLoader) (*Store[T], error) {
+	return NewStoreContext[T](context.Background(), loader)
+}
+
+// NewStoreContext is like NewStore but stops loading as soon as ctx is done.
+func NewStoreContext[T any](ctx context.Context, loader *DefaultLoader) (*Store[T], error) {
+	s := &Store[T]{loader: loader}
+
+	conf, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.current.Store(conf)
+	return s, nil
+}
+
+// Get returns the current config. It must not be modified.
+func (s *Store[T]) Get() *T {
+	return s.current.Load()
+}
+
+// Reload loads and validates a new config and replaces the current one with
+// it. If the new config cannot be loaded or is invalid, the current config
+// and the Provenance of the loader are kept and the error is returned.
+//
+// Fields tagged with `reloadable:"false"` can't change without a restart. If
+// one of them changes, the current config is kept and a RestartRequiredError
+// is returned.
+//
+// The subscribers are called in turn with the old and the new configs once
+// the new one is stored.
+func (s *Store[T]) Reload() error {
+	return s.ReloadContext(context.Background())
+}
+
+// ReloadContext is like Reload but stops loading as soon as ctx is done.
+func (s *Store[T]) ReloadContext(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	provenance := maps.Clone(s.loader.Provenance)
+
+	conf, err := s.load(ctx)
+	if err != nil {
+		// roll back the provenance, which was reset by the failed load
+		clear(s.loader.Provenance)
+		maps.Copy(s.loader.Provenance, provenance)
+		return err
+	}
+
+	changes, err := Diff(s.current.Load(), conf)
+	if err != nil {
+		return err
+	}
+
+	if restart := notReloadable(conf, changes); len(restart) > 0 {
+		clear(s.loader.Provenance)
+		maps.Copy(s.loader.Provenance, provenance)
+		return &RestartRequiredError{Changes: restart}
+	}
+
+	old := s.current.Swap(conf)
+
+	s.mu.Lock()
+	subscribers := append([]subscriber[T](nil), s.subscribers...)
+	s.mu.Unlock()
+
+	for _, sub := range subscribers {
+		if sub.path == "" {
+			sub.fn(old, conf, changes)
+			continue
+		}
+
+		if scoped := changesUnder(changes, sub.path); len(scoped) > 0 {
+			sub.fn(old, conf, scoped)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers fn to be called after each successful reload with the
+// old and the new configs. fn must not call Reload. The returned function
+// unregisters fn.
+func (s *Store[T]) Subscribe(fn func(old, new *T)) (unsubscribe func()) {
+	return s.subscribe("", func(old, new *T, _ []Change) {
+		fn(old, new)
+	})
+}
+
+// OnChange registers fn to be called after a successful reload which changes
+// the field at path, or one of its nested fields, i.e: "Postgres" or
+// "Log.Level". fn gets the old and the new configs, and the changes under
+// path, see Diff. fn must not call Reload. The returned function unregisters
+// fn. An error is returned if T has no field at path.
+func (s *Store[T]) OnChange(path string, fn func(old, new *T, changes []Change)) (unsubscribe func(), err error) {
+	if !hasFieldPath(s.Get(), path) {
+		return nil, fmt.Errorf("multiconfig: unknown field '%s'", path)
+	}
+
+	return s.subscribe(path, fn), nil
+}
+
+func (s *Store[T]) subscribe(path string, fn func(old, new *T, changes []Change)) (unsubscribe func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID
+	s.nextID++
+	s.subscribers = append(s.subscribers, subscriber[T]{id: id, path: path, fn: fn})
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for i, sub := range s.subscribers {
+			if sub.id == id {
+				s.subscribers = append(s.subscribers[:i:i], s.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// load returns a new config loaded and validated by the loader.
+func (s *Store[T]) load(ctx context.Context) (*T, error) {
+	conf := new(T)
+	if err := checkStructPointer(conf); err != nil {
+		return nil, err
+	}
+
+	if err := s.loader.LoadContext(ctx, conf); err != nil {
+		return nil, err
+	}
+
+	if s.loader.Validator != nil {
+		if err := s.loader.Validate(conf); err != nil {
+			return nil, err
+		}
+	}
+
+	return conf, nil
+}
+
+// ErrRestartRequired is matched by the RestartRequiredError errors, see
+// Store.Reload.
+var ErrRestartRequired = errors.New("multiconfig: restart required")
+
+// RestartRequiredError is returned by Store.Reload when fields tagged with
+// `reloadable:"false"` have changed.
+type RestartRequiredError struct {
+	// Changes holds the changes of the fields which are not reloadable.
+	Changes []Change
+}
+
+func (e *RestartRequiredError) Error() string {
+	paths := make([]string, len(e.Changes))
+	for i, c := range e.Changes {
+		paths[i] = c.Path
+	}
+
+	return fmt.Sprintf("multiconfig: restart required, fields are not reloadable: %s", strings.Join(paths, ", "))
+}
+
+func (e *RestartRequiredError) Is(target error) bool {
+	return target == ErrRestartRequired
+}
+
+// notReloadable returns the changes of the fields of the config s which are
+// tagged with `reloadable:"false"`, or nested in such a field.
+func notReloadable(s any, changes []Change) []Change {
+	var restart []Change
+	var walk func(fields []*configField)
+	walk = func(fields []*configField) {
+		for _, field := range fields {
+			if field.Field.Tag.Get("reloadable") == "false" {
+				restart = append(restart, changesUnder(changes, field.Name())...)
+				continue
+			}
+
+			walk(field.Fields)
+		}
+	}
+
+	walk(configFields(reflect.ValueOf(s), nil))
+	return restart
+}
+
+// changesUnder returns the changes of the field at path and of its nested
+// fields and elements.
+func changesUnder(changes []Change, path string) []Change {
+	var scoped []Change
+	for _, c := range changes {
+		if isUnderPath(c.Path, path) {
+			scoped = append(scoped, c)
+		}
+	}
+
+	return scoped
+}
+
+// isUnderPath reports whether the field path p is path or one of its nested
+// fields and elements, i.e: "Postgres.Port" and "Users[1]" are under
+// "Postgres" and "Users".
+func isUnderPath(p, path string) bool {
+	rest, ok := strings.CutPrefix(p, path)
+	return ok && (rest == "" || rest[0] == '.' || rest[0] == '[')
+}
+
+// hasFieldPath reports whether the config s has a field at path. Paths to
+// elements of slices and maps, i.e: "Users[1]", are accepted.
+func hasFieldPath(s any, path string) bool {
+	var walk func(fields []*configField) bool
+	walk = func(fields []*configField) bool {
+		for _, field := range fields {
+			name := field.Name()
+			if name == path || (!field.IsNested() && isUnderPath(path, name)) {
+				return true
+			}
+
+			if field.IsNested() && walk(field.Fields) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return walk(configFields(reflect.ValueOf(s), nil))
+}
@@ -0,0 +1,187 @@
+package multiconfig
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type StoreServer struct {
+	Name string `required:"true"`
+	Port int    `default:"6060"`
+}
+
+func newStoreLoader() *DefaultLoader {
+	p := Provenance{}
+	return &DefaultLoader{
+		Loader:     TrackProvenance(p, &TagLoader{}, &EnvironmentLoader{}),
+		Validator:  &RequiredValidator{},
+		Provenance: p,
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Setenv("STORESERVER_NAME", "koding")
+
+	loader := newStoreLoader()
+	s, err := NewStore[StoreServer](loader)
+	require.NoError(t, err)
+	require.Equal(t, &StoreServer{Name: "koding", Port: 6060}, s.Get())
+
+	type call struct{ old, new *StoreServer }
+	var calls []call
+	unsubscribe := s.Subscribe(func(old, new *StoreServer) {
+		calls = append(calls, call{old, new})
+	})
+
+	first := s.Get()
+	t.Setenv("STORESERVER_PORT", "4000")
+	require.NoError(t, s.Reload())
+	require.Equal(t, &StoreServer{Name: "koding", Port: 4000}, s.Get())
+	require.Equal(t, 6060, first.Port, "snapshots must not be modified")
+	require.Equal(t, []call{{first, s.Get()}}, calls)
+	require.Equal(t, "environment", loader.Provenance["Port"])
+
+	unsubscribe()
+	require.NoError(t, s.Reload())
+	require.Len(t, calls, 1)
+}
+
+func TestStoreRollback(t *testing.T) {
+	t.Setenv("STORESERVER_NAME", "koding")
+	t.Setenv("STORESERVER_PORT", "4000")
+
+	loader := newStoreLoader()
+	s, err := NewStore[StoreServer](loader)
+	require.NoError(t, err)
+
+	called := false
+	s.Subscribe(func(old, new *StoreServer) { called = true })
+
+	current := s.Get()
+
+	t.Setenv("STORESERVER_NAME", "")
+	t.Setenv("STORESERVER_PORT", "")
+	require.EqualError(t, s.Reload(), "multiconfig: field 'Name' is required")
+	require.Same(t, current, s.Get())
+	require.Equal(t, "environment", loader.Provenance["Port"])
+
+	t.Setenv("STORESERVER_NAME", "koding")
+	t.Setenv("STORESERVER_PORT", "port")
+	require.Error(t, s.Reload())
+	require.Same(t, current, s.Get())
+
+	require.False(t, called)
+}
+
+func TestStoreErrors(t *testing.T) {
+	_, err := NewStore[StoreServer](newStoreLoader())
+	require.EqualError(t, err, "multiconfig: field 'Name' is required")
+
+	_, err = NewStore[string](newStoreLoader())
+	require.ErrorIs(t, err, ErrNotStructPointer)
+}
+
+func TestStoreConcurrentGet(t *testing.T) {
+	t.Setenv("STORESERVER_NAME", "koding")
+
+	s, err := NewStore[StoreServer](newStoreLoader())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				require.Equal(t, "koding", s.Get().Name)
+			}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.Reload())
+	}
+
+	wg.Wait()
+}
+
+type ReloadServer struct {
+	Name     string
+	Listen   string `reloadable:"false"`
+	Log      struct{ Level string }
+	Postgres struct {
+		Host string
+		Port int
+	}
+}
+
+func TestStoreOnChange(t *testing.T) {
+	t.Setenv("RELOADSERVER_LOG_LEVEL", "info")
+	t.Setenv("RELOADSERVER_POSTGRES_PORT", "5432")
+
+	s, err := NewStore[ReloadServer](&DefaultLoader{Loader: &EnvironmentLoader{}})
+	require.NoError(t, err)
+
+	var postgres, level [][]Change
+	_, err = s.OnChange("Postgres", func(old, new *ReloadServer, changes []Change) {
+		postgres = append(postgres, changes)
+	})
+	require.NoError(t, err)
+
+	_, err = s.OnChange("Log.Level", func(old, new *ReloadServer, changes []Change) {
+		require.Equal(t, "info", old.Log.Level)
+		require.Equal(t, "debug", new.Log.Level)
+		level = append(level, changes)
+	})
+	require.NoError(t, err)
+
+	t.Setenv("RELOADSERVER_NAME", "koding")
+	require.NoError(t, s.Reload())
+	require.Empty(t, postgres)
+	require.Empty(t, level)
+
+	t.Setenv("RELOADSERVER_LOG_LEVEL", "debug")
+	t.Setenv("RELOADSERVER_POSTGRES_HOST", "db")
+	t.Setenv("RELOADSERVER_POSTGRES_PORT", "5433")
+	require.NoError(t, s.Reload())
+	require.Equal(t, [][]Change{{
+		{Path: "Postgres.Host", Old: "", New: "db"},
+		{Path: "Postgres.Port", Old: 5432, New: 5433},
+	}}, postgres)
+	require.Equal(t, [][]Change{{{Path: "Log.Level", Old: "info", New: "debug"}}}, level)
+
+	_, err = s.OnChange("Postgres.User", func(old, new *ReloadServer, changes []Change) {})
+	require.EqualError(t, err, "multiconfig: unknown field 'Postgres.User'")
+}
+
+func TestStoreRestartRequired(t *testing.T) {
+	t.Setenv("RELOADSERVER_LISTEN", ":8080")
+
+	s, err := NewStore[ReloadServer](&DefaultLoader{Loader: &EnvironmentLoader{}})
+	require.NoError(t, err)
+
+	called := false
+	s.Subscribe(func(old, new *ReloadServer) { called = true })
+
+	current := s.Get()
+
+	t.Setenv("RELOADSERVER_NAME", "koding")
+	t.Setenv("RELOADSERVER_LISTEN", ":9090")
+	err = s.Reload()
+	require.ErrorIs(t, err, ErrRestartRequired)
+	require.EqualError(t, err, "multiconfig: restart required, fields are not reloadable: Listen")
+
+	var restartErr *RestartRequiredError
+	require.ErrorAs(t, err, &restartErr)
+	require.Equal(t, []Change{{Path: "Listen", Old: ":8080", New: ":9090"}}, restartErr.Changes)
+
+	require.Same(t, current, s.Get())
+	require.False(t, called)
+
+	t.Setenv("RELOADSERVER_LISTEN", ":8080")
+	require.NoError(t, s.Reload())
+	require.Equal(t, "koding", s.Get().Name)
+	require.True(t, called)
+}
@@ -1,6 +1,8 @@
 package multiconfig
 
 import (
+	"fmt"
+	"log/slog"
 	"reflect"
 
 	"github.com/fatih/structs"
@@ -17,16 +19,24 @@ type TagLoader struct {
 	//
 	// The default value is "default" if it's not set explicitly.
 	DefaultTagName string
+
+	// Logger logs the decisions of the loader at the debug level. Nothing
+	// is logged if it's nil.
+	Logger *slog.Logger
 }
 
 func (t *TagLoader) Load(s any) error {
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
 	if t.DefaultTagName == "" {
 		t.DefaultTagName = "default"
 	}
 
 	for _, field := range structs.Fields(s) {
 
-		if err := processField(t.DefaultTagName, field); err != nil {
+		if err := processField(t.Logger, t.DefaultTagName, "", field); err != nil {
 			return err
 		}
 	}
@@ -35,12 +45,18 @@ func (t *TagLoader) Load(s any) error {
 }
 
 // processField gets tagName and the field, recursively checks if the field has the given
-// tag, if yes, sets it otherwise ignores
-func processField(tagName string, field *structs.Field) error {
+// tag, if yes, sets it otherwise ignores. fieldName is the dotted path of the
+// parent struct.
+func processField(logger *slog.Logger, tagName, fieldName string, field *structs.Field) error {
+	if fieldName != "" {
+		fieldName += "."
+	}
+	fieldName += field.Name()
+
 	switch field.Kind() {
 	case reflect.Struct:
 		for _, f := range field.Fields() {
-			if err := processField(tagName, f); err != nil {
+			if err := processField(logger, tagName, fieldName, f); err != nil {
 				return err
 			}
 		}
@@ -52,7 +68,16 @@ func processField(tagName string, field *structs.Field) error {
 
 		err := fieldSet(field, defaultVal)
 		if err != nil {
-			return err
+			return &LoadError{
+				Loader: "default tag",
+				Source: fmt.Sprintf("%s:%q", tagName, defaultVal),
+				Field:  fieldName,
+				Err:    err,
+			}
+		}
+
+		if logger != nil {
+			logger.Debug("default applied", "field", fieldName, "value", logValue(defaultVal, isSecretField(field)))
 		}
 	}
 
@@ -0,0 +1,251 @@
+package multiconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URLLoader satisfies the loader interface. It fetches a config file over
+// HTTP(S) and loads it with the file loader of its format. The file is cached
+// with its ETag, so it's only downloaded again once it has changed, i.e: when
+// reloading a Store.
+type URLLoader struct {
+	// URL is the URL of the config file.
+	URL string
+
+	// Format is the format of the file. By default it's defined by the
+	// Content-Type of the response, or by the extension of the URL if the
+	// Content-Type is unknown.
+	Format Format
+
+	// Header holds the headers sent with the request.
+	Header http.Header
+
+	// BearerToken is sent in the Authorization header, if set.
+	BearerToken string
+
+	// Timeout bounds the request. The default is 30 seconds.
+	Timeout time.Duration
+
+	// MaxSize is the maximum size of the config file in bytes. The default
+	// is 10 MiB.
+	MaxSize int64
+
+	// CAFile is the path of a PEM file holding certificate authorities
+	// trusted in addition to the ones of the system, i.e: an internal CA.
+	CAFile string
+
+	// TLSConfig is the TLS configuration of the client. CAFile is added to
+	// its RootCAs.
+	TLSConfig *tls.Config
+
+	// Client is the HTTP client used for the request. If set, CAFile and
+	// TLSConfig are ignored.
+	Client *http.Client
+
+	// Strict makes keys which don't match any field an error, see
+	// UnknownKeysError.
+	Strict bool
+
+	// Logger logs the decisions of the loader at the debug level. Nothing
+	// is logged if it's nil.
+	Logger *slog.Logger
+
+	mu     sync.Mutex
+	client *http.Client
+	etag   string
+	data   []byte
+	format Format
+}
+
+// isURL reports whether the config path is an HTTP(S) URL.
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// Load loads the source into the config defined by struct s
+func (u *URLLoader) Load(s any) error {
+	return u.LoadContext(context.Background(), s)
+}
+
+// LoadContext is like Load but stops as soon as ctx is done.
+func (u *URLLoader) LoadContext(ctx context.Context, s any) error {
+	if err := checkStructPointer(s); err != nil {
+		return err
+	}
+
+	data, format, err := u.fetch(ctx)
+	if err != nil {
+		return &LoadError{Loader: "url", Source: u.URL, Err: err}
+	}
+
+	// the URL is kept to describe the source
+	loader := newFileLoader(format, u.URL, bytes.NewReader(data), u.Strict)
+	setLogger(loader, u.Logger)
+	return loader.Load(s)
+}
+
+// fetch returns the content of the config file and its format.
+func (u *URLLoader) fetch(ctx context.Context) ([]byte, Format, error) {
+	timeout := u.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.URL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for key, values := range u.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	if u.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+u.BearerToken)
+	}
+
+	u.mu.Lock()
+	etag, cached, cachedFormat := u.etag, u.data, u.format
+	u.mu.Unlock()
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	client, err := u.httpClient()
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		u.log("config url not modified", "etag", etag)
+		return cached, cachedFormat, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", errors.New(resp.Status)
+	}
+
+	maxSize := u.MaxSize
+	if maxSize <= 0 {
+		maxSize = 10 << 20
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, "", fmt.Errorf("config file larger than %d bytes", maxSize)
+	}
+
+	format, ok := u.responseFormat(resp.Header.Get("Content-Type"))
+	if !ok {
+		return nil, "", fmt.Errorf("unknown config format %q", resp.Header.Get("Content-Type"))
+	}
+
+	u.mu.Lock()
+	u.etag, u.data, u.format = resp.Header.Get("ETag"), data, format
+	u.mu.Unlock()
+
+	u.log("config url fetched", "format", format, "etag", resp.Header.Get("ETag"))
+	return data, format, nil
+}
+
+// responseFormat returns the format of a response with the given
+// Content-Type.
+func (u *URLLoader) responseFormat(contentType string) (Format, bool) {
+	if u.Format != "" {
+		return u.Format, true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		return FormatJSON, true
+	case "application/toml", "text/toml", "text/x-toml":
+		return FormatTOML, true
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return FormatYAML, true
+	}
+
+	if parsed, err := url.Parse(u.URL); err == nil {
+		return fileFormat(parsed.Path)
+	}
+
+	return "", false
+}
+
+func (u *URLLoader) httpClient() (*http.Client, error) {
+	if u.Client != nil {
+		return u.Client, nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.client != nil {
+		return u.client, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if u.TLSConfig != nil {
+		tlsConfig = u.TLSConfig.Clone()
+	}
+
+	if u.CAFile != "" {
+		pem, err := os.ReadFile(u.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var pool *x509.CertPool
+		if tlsConfig.RootCAs != nil {
+			pool = tlsConfig.RootCAs.Clone()
+		} else if pool, err = x509.SystemCertPool(); err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", u.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	u.client = &http.Client{Transport: transport}
+	return u.client, nil
+}
+
+func (u *URLLoader) log(msg string, args ...any) {
+	if u.Logger != nil {
+		u.Logger.Debug(msg, append([]any{"url", u.URL}, args...)...)
+	}
+}
@@ -0,0 +1,200 @@
+package multiconfig
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// configServer serves a config file with an ETag and counts the responses by
+// status.
+type configServer struct {
+	mu          sync.Mutex
+	contentType string
+	data        []byte
+	etag        string
+	statuses    []int
+}
+
+func (c *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer t0k3n" || r.Header.Get("X-Env") != "test" {
+		c.statuses = append(c.statuses, http.StatusUnauthorized)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if r.Header.Get("If-None-Match") == c.etag {
+		c.statuses = append(c.statuses, http.StatusNotModified)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	c.statuses = append(c.statuses, http.StatusOK)
+	w.Header().Set("Content-Type", c.contentType)
+	w.Header().Set("ETag", c.etag)
+	w.Write(c.data)
+}
+
+func (c *configServer) set(data, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data, c.etag = []byte(data), etag
+}
+
+func TestURLLoader(t *testing.T) {
+	data, err := os.ReadFile(testYAML)
+	require.NoError(t, err)
+
+	c := &configServer{contentType: "application/yaml; charset=utf-8", data: data, etag: `"v1"`}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	u := &URLLoader{
+		URL:         srv.URL + "/config",
+		Header:      http.Header{"X-Env": {"test"}},
+		BearerToken: "t0k3n",
+	}
+
+	s := &Server{}
+	require.NoError(t, MultiLoader(&TagLoader{}, u).Load(s))
+	testStruct(t, s, getDefaultServer())
+
+	s = &Server{}
+	require.NoError(t, u.Load(s))
+	require.Equal(t, "koding", s.Name)
+
+	c.set("name: gopher\n", `"v2"`)
+	s = &Server{}
+	require.NoError(t, u.Load(s))
+	require.Equal(t, "gopher", s.Name)
+
+	require.Equal(t, []int{http.StatusOK, http.StatusNotModified, http.StatusOK}, c.statuses)
+
+	err = (&URLLoader{URL: srv.URL + "/config"}).Load(&Server{})
+	require.EqualError(t, err, "multiconfig: url "+srv.URL+"/config: 401 Unauthorized")
+}
+
+func TestURLLoaderFormat(t *testing.T) {
+	c := &configServer{contentType: "text/plain", data: []byte(`name = "koding"`), etag: `"v1"`}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	header := http.Header{"X-Env": {"test"}}
+
+	s := &Server{}
+	require.NoError(t, (&URLLoader{URL: srv.URL + "/config.toml?v=1", Header: header, BearerToken: "t0k3n"}).Load(s))
+	require.Equal(t, "koding", s.Name)
+
+	s = &Server{}
+	require.NoError(t, (&URLLoader{URL: srv.URL + "/config", Format: FormatTOML, Header: header, BearerToken: "t0k3n"}).Load(s))
+	require.Equal(t, "koding", s.Name)
+
+	err := (&URLLoader{URL: srv.URL + "/config", Header: header, BearerToken: "t0k3n"}).Load(&Server{})
+	require.EqualError(t, err, "multiconfig: url "+srv.URL+`/config: unknown config format "text/plain"`)
+
+	c.set(`name = 1`, `"v2"`)
+	err = (&URLLoader{URL: srv.URL + "/config.toml", Header: header, BearerToken: "t0k3n"}).Load(&Server{})
+	require.ErrorContains(t, err, "multiconfig: toml file "+srv.URL+"/config.toml:1: field 'Name'")
+}
+
+func TestURLLoaderTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Name": "koding"}`))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	err := (&URLLoader{URL: srv.URL}).Load(&Server{})
+	require.ErrorContains(t, err, "certificate")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, ca, 0o600))
+
+	s := &Server{}
+	require.NoError(t, (&URLLoader{URL: srv.URL, CAFile: caFile}).Load(s))
+	require.Equal(t, "koding", s.Name)
+}
+
+func TestURLLoaderTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	err := (&URLLoader{URL: srv.URL + "/config.json", Timeout: 10 * time.Millisecond}).Load(&Server{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNewWithPathURL(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer srv.Close()
+
+	m := NewWithPath(srv.URL + "/config.json")
+
+	s := &Server{}
+	require.NoError(t, m.Load(s))
+	testStruct(t, s, getDefaultServer())
+	require.Equal(t, "url "+srv.URL+"/config.json", m.Provenance["Name"])
+
+	l, err := Load[Server](WithPath(srv.URL+"/config.yaml"), WithArgs([]string{}))
+	require.NoError(t, err)
+	require.Equal(t, "koding", l.Name)
+}
+
+func TestNewWithURL(t *testing.T) {
+	data, err := os.ReadFile(testJSON)
+	require.NoError(t, err)
+
+	c := &configServer{contentType: "application/json", data: data, etag: `"v1"`}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	newURLLoader := func() *URLLoader {
+		return &URLLoader{
+			URL:         srv.URL + "/config",
+			Header:      http.Header{"X-Env": {"test"}},
+			BearerToken: "t0k3n",
+		}
+	}
+
+	m := NewWithURL(newURLLoader())
+
+	s := &Server{}
+	require.NoError(t, m.Load(s))
+	testStruct(t, s, getDefaultServer())
+	require.Equal(t, "url "+srv.URL+"/config", m.Provenance["Name"])
+
+	l, err := Load[Server](WithURLLoader(newURLLoader()), WithArgs([]string{}))
+	require.NoError(t, err)
+	require.Equal(t, "koding", l.Name)
+
+	_, err = Load[Server](WithPath(srv.URL+"/config"), WithArgs([]string{}))
+	require.EqualError(t, err, "multiconfig: url "+srv.URL+"/config: 401 Unauthorized")
+}
+
+func TestURLLoaderMaxSize(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer srv.Close()
+
+	err := (&URLLoader{URL: srv.URL + "/config.json", MaxSize: 16}).Load(&Server{})
+	require.EqualError(t, err, "multiconfig: url "+srv.URL+"/config.json: config file larger than 16 bytes")
+
+	require.NoError(t, (&URLLoader{URL: srv.URL + "/config.json", MaxSize: 1 << 10}).Load(&Server{}))
+}
@@ -0,0 +1,163 @@
+package multiconfig
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func (f *FlagLoader) output() io.Writer {
+	if f.Output != nil {
+		return f.Output
+	}
+
+	return os.Stderr
+}
+
+func (f *FlagLoader) usageWidth() int {
+	if f.UsageWidth > 0 {
+		return f.UsageWidth
+	}
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	return 80
+}
+
+// WriteUsage writes the usage of the flags generated for the config struct s
+// to w. Flags are grouped by nested struct and each one is listed with its
+// type, default value, whether it's required, the matching environment
+// variable and its description, wrapped to UsageWidth. Fields tagged with
+// `hidden:"true"` are omitted. The ProfileFlag, if any, is listed first.
+// It's the usage printed when the -help flag is passed.
+func (f *FlagLoader) WriteUsage(w io.Writer, s any) error {
+	e := &EnvironmentLoader{
+		Prefix:    f.EnvPrefix,
+		CamelCase: f.CamelCase,
+	}
+
+	list, err := settings(s, f, e)
+	if err != nil {
+		return err
+	}
+
+	// the root-level flags are listed first, so they're not mistaken for
+	// flags of the group above them
+	slices.SortStableFunc(list, func(a, b *setting) int {
+		switch {
+		case a.Group() == "" && b.Group() != "":
+			return -1
+		case a.Group() != "" && b.Group() == "":
+			return 1
+		}
+		return 0
+	})
+
+	width := f.usageWidth()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage of %s:\n", os.Args[0])
+
+	const indent = "        "
+	if f.ProfileFlag != "" {
+		b.WriteString("  -" + f.ProfileFlag + " string\n")
+		b.WriteString(indent + "Name of the configuration profile.\n")
+	}
+
+	group := ""
+	for _, st := range list {
+		if st.Flag == "" || st.Hidden {
+			continue
+		}
+
+		if g := st.Group(); g != group {
+			group = g
+			fmt.Fprintf(&b, "\n%s:\n", group)
+		}
+
+		line := "  -" + st.Flag
+		if st.Type != "bool" {
+			line += " " + st.Type
+		}
+
+		// the default value is the value before parsing the flags, which
+		// includes the values loaded from the other sources
+		defValue := ""
+		if f.flagSet != nil {
+			if fl := f.flagSet.Lookup(st.Flag); fl != nil {
+				defValue = fl.DefValue
+			}
+		}
+
+		zero := reflect.Zero(st.field.Field.Type).Interface()
+		if defValue == fmt.Sprintf("%v", zero) {
+			defValue = ""
+		}
+
+		var attrs []string
+		if defValue != "" {
+			attrs = append(attrs, "default "+strconv.Quote(defValue))
+		}
+
+		if st.Required {
+			attrs = append(attrs, "required")
+		}
+
+		if st.Deprecated != "" {
+			attrs = append(attrs, "deprecated: "+st.Deprecated)
+		}
+
+		if len(attrs) > 0 {
+			line += " (" + strings.Join(attrs, ", ") + ")"
+		}
+
+		b.WriteString(line + "\n")
+
+		for _, l := range wrapText(st.Usage, width-len(indent)) {
+			b.WriteString(indent + l + "\n")
+		}
+
+		if st.Env != "" {
+			b.WriteString(indent + "env: " + st.Env + "\n")
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// wrapText splits s in lines of at most width characters, breaking on spaces.
+// Words longer than width are not split and existing line breaks are kept.
+func wrapText(s string, width int) []string {
+	if width < 20 {
+		width = 20
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case len(line)+1+len(word) > width:
+				lines = append(lines, line)
+				line = word
+			default:
+				line += " " + word
+			}
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,94 @@
+package multiconfig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type UsageServer struct {
+	Name     string `required:"true" flagUsage:"Name of the server, used to identify it in the logs and in the metrics"`
+	Port     int    `default:"6060"`
+	Enabled  bool
+	Password Secret `default:"changeme"`
+	Postgres struct {
+		Hosts []string `required:"true"`
+	}
+}
+
+func TestFlagUsage(t *testing.T) {
+	var buf bytes.Buffer
+
+	s := &UsageServer{}
+	require.NoError(t, (&TagLoader{}).Load(s))
+
+	m := &FlagLoader{
+		EnvPrefix:  "app",
+		Output:     &buf,
+		UsageWidth: 48,
+		Args:       []string{"-help"},
+	}
+	require.ErrorIs(t, m.Load(s), flag.ErrHelp)
+
+	expected := "Usage of " + os.Args[0] + `:
+  -name string (required)
+        Name of the server, used to identify it
+        in the logs and in the metrics
+        env: APP_NAME
+  -port int (default "6060")
+        Change value of Port.
+        env: APP_PORT
+  -enabled
+        Change value of Enabled.
+        env: APP_ENABLED
+  -password string (default "******")
+        Change value of Password.
+        env: APP_PASSWORD
+
+Postgres:
+  -postgres-hosts []string (required)
+        Change value of Hosts.
+        env: APP_POSTGRES_HOSTS
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestFlagUsageRootAfterGroup(t *testing.T) {
+	var buf bytes.Buffer
+
+	s := &struct {
+		A        string
+		Postgres struct{ Host string }
+		B        string
+	}{}
+	require.NoError(t, (&FlagLoader{EnvPrefix: "app"}).WriteUsage(&buf, s))
+
+	expected := "Usage of " + os.Args[0] + `:
+  -a string
+        Change value of A.
+        env: APP_A
+  -b string
+        Change value of B.
+        env: APP_B
+
+Postgres:
+  -postgres-host string
+        Change value of Host.
+        env: APP_POSTGRES_HOST
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestWrapText(t *testing.T) {
+	text := "Lorem ipsum dolor sit amet, consectetur adipiscing elit\nsed do"
+	require.Equal(t, []string{
+		"Lorem ipsum dolor sit",
+		"amet, consectetur",
+		"adipiscing elit",
+		"sed do",
+	}, wrapText(text, 21))
+	require.Equal(t, []string(nil), wrapText("", 80))
+}
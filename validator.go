@@ -3,6 +3,11 @@ package multiconfig
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/fatih/structs"
 )
@@ -28,6 +33,21 @@ type RequiredValidator struct {
 // intentionaly, the value of a field is `zero-valued`(e.g false, 0, "")
 // required tag should not be set for that field.
 func (e *RequiredValidator) Validate(s any) error {
+	if errs := e.validate(s); len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// validate returns an error for every required field which is not set.
+func (e *RequiredValidator) validate(s any) []error {
+	if !structs.IsStruct(s) {
+		return []error{&notStructPointerError{
+			msg: fmt.Sprintf("multiconfig: %T is not a struct", s),
+		}}
+	}
+
 	if e.TagName == "" {
 		e.TagName = "required"
 	}
@@ -36,16 +56,15 @@ func (e *RequiredValidator) Validate(s any) error {
 		e.TagValue = "true"
 	}
 
+	var errs []error
 	for _, field := range structs.Fields(s) {
-		if err := e.processField("", field); err != nil {
-			return err
-		}
+		e.processField("", field, &errs)
 	}
 
-	return nil
+	return errs
 }
 
-func (e *RequiredValidator) processField(fieldName string, field *structs.Field) error {
+func (e *RequiredValidator) processField(fieldName string, field *structs.Field, errs *[]error) {
 	fieldName += field.Name()
 	switch field.Kind() {
 	case reflect.Struct:
@@ -54,18 +73,145 @@ func (e *RequiredValidator) processField(fieldName string, field *structs.Field)
 		fieldName += "."
 
 		for _, f := range field.Fields() {
-			if err := e.processField(fieldName, f); err != nil {
-				return err
-			}
+			e.processField(fieldName, f, errs)
 		}
 	default:
 		val := field.Tag(e.TagName)
 		if val != e.TagValue {
-			return nil
+			return
 		}
 
 		if field.IsZero() {
-			return fmt.Errorf("multiconfig: field '%s' is required", fieldName)
+			*errs = append(*errs, fmt.Errorf("multiconfig: field '%s' is required", fieldName))
+		}
+	}
+}
+
+// ConstraintValidator validates the struct against the constraints of the
+// "oneof", "min", "max" and "pattern" tags, which are reflected in the schema
+// generated by JSONSchema:
+//
+//	oneof:"debug info"     the value is one of the listed values
+//	min:"1" max:"65535"    bounds of a number, of the length of a string or
+//	                       of the number of elements of a slice or a map
+//	pattern:"^[a-z]+$"     the string matches the regular expression
+//
+// Fields with a zero value are not checked, tag them with required:"true" to
+// make them mandatory. The loaders returned by New, NewWithPath and
+// NewWithProfile only use a RequiredValidator, set their Validator to a
+// MultiValidator with a ConstraintValidator or use Load with WithConstraints
+// to check the constraints.
+type ConstraintValidator struct{}
+
+// Validate validates the given struct against the constraints of its tags.
+func (c *ConstraintValidator) Validate(s any) error {
+	if errs := c.validate(s); len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// validate returns an error for every constraint which is not satisfied.
+func (c *ConstraintValidator) validate(s any) []error {
+	if !structs.IsStruct(s) {
+		return []error{&notStructPointerError{
+			msg: fmt.Sprintf("multiconfig: %T is not a struct", s),
+		}}
+	}
+
+	var errs []error
+	for _, field := range leafFields(configFields(reflect.ValueOf(s), nil)) {
+		v := field.Value
+		for v.Kind() == reflect.Pointer && !v.IsNil() {
+			v = v.Elem()
+		}
+
+		if v.Kind() == reflect.Pointer || v.IsZero() {
+			continue
+		}
+
+		if err := checkConstraints(field.Field.Tag, v); err != nil {
+			errs = append(errs, fmt.Errorf("multiconfig: field '%s' %w", field.Name(), err))
+		}
+	}
+
+	return errs
+}
+
+// checkConstraints returns an error if v doesn't satisfy the constraints of
+// the tags.
+func checkConstraints(tag reflect.StructTag, v reflect.Value) error {
+	// the oneof and pattern tags apply to each element of a slice
+	elems := []reflect.Value{v}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		elems = nil
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, reflect.Indirect(v.Index(i)))
+		}
+	}
+
+	if oneof := tag.Get("oneof"); oneof != "" {
+		values := strings.Fields(oneof)
+		for _, elem := range elems {
+			if !slices.Contains(values, fmt.Sprint(elem.Interface())) {
+				return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+			}
+		}
+	}
+
+	var size float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = v.Float()
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Array:
+		size, unit = float64(v.Len()), " elements"
+	case reflect.Map:
+		size, unit = float64(v.Len()), " entries"
+	}
+
+	for _, name := range []string{"min", "max"} {
+		val := tag.Get(name)
+		if val == "" {
+			continue
+		}
+
+		bound, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("has an invalid %s tag '%s': %w", name, val, err)
+		}
+
+		verb := "be"
+		if unit != "" {
+			verb = "have"
+		}
+
+		if name == "min" && size < bound {
+			return fmt.Errorf("must %s at least %s%s", verb, val, unit)
+		}
+
+		if name == "max" && size > bound {
+			return fmt.Errorf("must %s at most %s%s", verb, val, unit)
+		}
+	}
+
+	if pattern := tag.Get("pattern"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("has an invalid pattern tag '%s': %w", pattern, err)
+		}
+
+		for _, elem := range elems {
+			if elem.Kind() == reflect.String && !re.MatchString(elem.String()) {
+				return fmt.Errorf("must match '%s'", pattern)
+			}
 		}
 	}
 
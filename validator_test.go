@@ -1,6 +1,10 @@
 package multiconfig
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
 
 func TestValidators(t *testing.T) {
 	s := getDefaultServer()
@@ -48,3 +52,56 @@ func TestValidatorsCustomTag(t *testing.T) {
 		t.Fatalf("Err string is wrong: expected %s, got: %s", errStr, err.Error())
 	}
 }
+
+type ConstraintServer struct {
+	Level  string   `oneof:"debug info error"`
+	Port   int      `min:"1" max:"65535"`
+	Name   string   `min:"3" pattern:"^[a-z]+$"`
+	Hosts  []string `max:"2" oneof:"db1 db2 db3"`
+	Weight *float64 `max:"1"`
+	Tags   []string `pattern:"^[a-z]+$"`
+}
+
+func TestConstraintValidator(t *testing.T) {
+	weight := 0.5
+	valid := ConstraintServer{Level: "info", Port: 8080, Name: "koding", Hosts: []string{"db1", "db3"}, Weight: &weight}
+
+	v := &ConstraintValidator{}
+	require.NoError(t, v.Validate(valid))
+	require.NoError(t, v.Validate(&valid))
+	require.NoError(t, v.Validate(&ConstraintServer{}), "zero values are not checked")
+
+	weight = 2
+	invalid := &ConstraintServer{Level: "trace", Port: 70000, Name: "Ko", Hosts: []string{"db1", "db4", "db2"}, Weight: &weight}
+	var msgs []string
+	for _, err := range v.validate(invalid) {
+		msgs = append(msgs, err.Error())
+	}
+
+	require.Equal(t, []string{
+		"multiconfig: field 'Level' must be one of debug, info, error",
+		"multiconfig: field 'Port' must be at most 65535",
+		"multiconfig: field 'Name' must have at least 3 characters",
+		"multiconfig: field 'Hosts' must be one of db1, db2, db3",
+		"multiconfig: field 'Weight' must be at most 1",
+	}, msgs)
+
+	require.EqualError(t, v.Validate(&ConstraintServer{Name: "koding1"}), "multiconfig: field 'Name' must match '^[a-z]+$'")
+	require.EqualError(t, v.Validate(&ConstraintServer{Hosts: []string{"db1", "db2", "db3"}}), "multiconfig: field 'Hosts' must have at most 2 elements")
+
+	require.EqualError(t, v.Validate(&ConstraintServer{Tags: []string{"web", "DB"}}), "multiconfig: field 'Tags' must match '^[a-z]+$'")
+
+	require.ErrorIs(t, v.Validate(42), ErrNotStructPointer)
+}
+
+func TestConstraintValidatorDefault(t *testing.T) {
+	t.Setenv("CONSTRAINTSERVER_LEVEL", "trace")
+
+	// the constraints are only checked on demand
+	s := &ConstraintServer{}
+	require.NoError(t, New().Load(s))
+	require.NoError(t, New().Validate(s))
+
+	_, err := Load[ConstraintServer](WithConstraints(), WithArgs([]string{}))
+	require.EqualError(t, err, "multiconfig: field 'Level' must be one of debug, info, error")
+}
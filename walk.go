@@ -0,0 +1,192 @@
+package multiconfig
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// configField is an exported field of a config struct. Nested structs have
+// their own fields in Fields, all the other fields are leaves.
+type configField struct {
+	// Path holds the names of the field and its parents, i.e:
+	// ["Postgres", "Port"]
+	Path []string
+
+	Field reflect.StructField
+
+	// Value is the value of the field. Nested structs behind a nil pointer
+	// are represented by a read-only zero value.
+	Value reflect.Value
+
+	Fields []*configField
+}
+
+// Name returns the dotted path of the field, the same way validators name
+// fields in their errors, i.e: "Postgres.Port".
+func (c *configField) Name() string {
+	return strings.Join(c.Path, ".")
+}
+
+// IsNested reports whether the field is a nested struct.
+func (c *configField) IsNested() bool {
+	return c.Fields != nil
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	flagValueType     = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
+// isNestedStruct reports whether values of type t are walked into as nested
+// structs. Structs which know how to represent themselves as a single value
+// (e.g: time.Time) are leaves.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for _, typ := range []reflect.Type{t, reflect.PointerTo(t)} {
+		if typ.Implements(textMarshalerType) || typ.Implements(flagValueType) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// configFields returns the exported fields of the struct (or pointer to
+// struct) v, recursively.
+func configFields(v reflect.Value, parent []string) []*configField {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v = reflect.Zero(v.Type().Elem())
+		} else {
+			v = v.Elem()
+		}
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := []*configField{}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		path := make([]string, len(parent), len(parent)+1)
+		copy(path, parent)
+
+		field := &configField{
+			Path:  append(path, sf.Name),
+			Field: sf,
+			Value: v.Field(i),
+		}
+
+		if isNestedStruct(sf.Type) {
+			field.Fields = configFields(field.Value, field.Path)
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// leafFields returns the leaves of the given fields, depth first.
+func leafFields(fields []*configField) []*configField {
+	leaves := []*configField{}
+	for _, field := range fields {
+		if field.IsNested() {
+			leaves = append(leaves, leafFields(field.Fields)...)
+			continue
+		}
+
+		leaves = append(leaves, field)
+	}
+
+	return leaves
+}
+
+// copyValue returns a deep copy of v, so it isn't affected by further
+// modifications of the slices, maps and pointers v holds.
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+
+		return c
+	case reflect.Pointer:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyValue(v.Elem()))
+		return c
+	default:
+		return v
+	}
+}
+
+// ErrNotStructPointer is returned by the built-in loaders when the config
+// they load into is not a non-nil pointer to a struct.
+var ErrNotStructPointer = errors.New("multiconfig: target must be a non-nil pointer to a struct")
+
+// notStructPointerError is an ErrNotStructPointer with a specific message.
+type notStructPointerError struct {
+	msg string
+}
+
+func (e *notStructPointerError) Error() string {
+	return e.msg
+}
+
+func (e *notStructPointerError) Is(target error) bool {
+	return target == ErrNotStructPointer
+}
+
+// checkStructPointer returns an ErrNotStructPointer error if s is not a
+// non-nil pointer to a struct, which is what the loaders need to set the
+// fields of s.
+func checkStructPointer(s any) error {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return &notStructPointerError{
+			msg: fmt.Sprintf("multiconfig: %T is not a non-nil pointer to a struct", s),
+		}
+	}
+
+	return nil
+}